@@ -0,0 +1,164 @@
+package liveprogress
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// captureDrainTimeout is the maximum time Stop() waits for the captured outputs to be written once they are restored.
+	captureDrainTimeout = 200 * time.Millisecond
+)
+
+var (
+	captureStdio  bool
+	capture       *stdioCapture
+	captureAccess sync.Mutex
+)
+
+// CaptureStdio enables the capture of the standard outputs for the next Start() calls.
+// While liveprogress is running, everything written to os.Stdout and os.Stderr (by your code or by third party libraries)
+// and by the standard log package is line buffered and written above the live progress thru Bypass().
+// On Unix like systems the file descriptors themselves are redirected, so even direct writes to them are captured;
+// on others systems only the os.Stdout and os.Stderr variables are swapped.
+// Stop() restores the original outputs (and log package output). Capture is skipped if the live progress is disabled.
+// Child processes inheriting the captured outputs (exec.Cmd Stdout or Stderr set to os.Stdout or os.Stderr) keep them open:
+// Stop() only waits a short delay for them, their later lines are still forwarded but directly to the restored outputs.
+func CaptureStdio() {
+	captureAccess.Lock()
+	captureStdio = true
+	captureAccess.Unlock()
+}
+
+// DisableCaptureStdio disables the capture enabled by CaptureStdio() for the next Start() calls.
+// If liveprogress is running, the standard outputs are still captured until Stop().
+func DisableCaptureStdio() {
+	captureAccess.Lock()
+	captureStdio = false
+	captureAccess.Unlock()
+}
+
+type stdioCapture struct {
+	stdout, stderr *redirection
+	logOutput      io.Writer
+	logWriter      *lineWriter
+	readers        sync.WaitGroup
+}
+
+// startCapture redirects the standard outputs and returns the writer liveprogress should draw on:
+// the original terminal if output is one of the standard outputs or output itself otherwise.
+func startCapture(output io.Writer) (target io.Writer, err error) {
+	defer captureAccess.Unlock()
+	captureAccess.Lock()
+	if !captureStdio || capture != nil {
+		return output, nil
+	}
+	c := &stdioCapture{}
+	if c.stdout, err = redirect(&os.Stdout); err != nil {
+		return
+	}
+	if c.stderr, err = redirect(&os.Stderr); err != nil {
+		c.stdout.restore()
+		c.stdout.reader.Close()
+		c.stdout.release()
+		return
+	}
+	for _, r := range []*redirection{c.stdout, c.stderr} {
+		c.readers.Add(1)
+		go c.forward(r.reader)
+	}
+	c.logWriter = &lineWriter{}
+	c.logOutput = log.Writer()
+	log.SetOutput(c.logWriter)
+	capture = c
+	switch output {
+	case c.stdout.captured:
+		return c.stdout.original, nil
+	case c.stderr.captured:
+		return c.stderr.original, nil
+	default:
+		return output, nil
+	}
+}
+
+// stopCapture restores the standard outputs and waits (at most captureDrainTimeout) for the captured data to be written thru Bypass().
+// The returned function must be called once the screen is stopped to release the original outputs duplicates.
+func stopCapture() (release func()) {
+	defer captureAccess.Unlock()
+	captureAccess.Lock()
+	if capture == nil {
+		return func() {}
+	}
+	c := capture
+	log.SetOutput(c.logOutput)
+	c.logWriter.Flush()
+	c.stdout.restore()
+	c.stderr.restore()
+	drained := make(chan struct{})
+	go func() {
+		c.readers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-time.After(captureDrainTimeout):
+		// the pipes are still open by child processes: keep forwarding their outputs in the background
+	}
+	capture = nil
+	return func() {
+		c.stdout.release()
+		c.stderr.release()
+	}
+}
+
+func (c *stdioCapture) forward(reader io.ReadCloser) {
+	defer c.readers.Done()
+	defer reader.Close()
+	lw := &lineWriter{}
+	buf := make([]byte, 4096)
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			_, _ = lw.Write(buf[:n])
+		}
+		if err != nil {
+			lw.Flush()
+			return
+		}
+	}
+}
+
+// lineWriter writes complete lines thru Bypass(), keeping incomplete ones until they are completed or flushed.
+type lineWriter struct {
+	pending bytes.Buffer
+	access  sync.Mutex
+}
+
+func (lw *lineWriter) Write(p []byte) (n int, err error) {
+	defer lw.access.Unlock()
+	lw.access.Lock()
+	n = len(p)
+	lw.pending.Write(p)
+	if lastNewLine := bytes.LastIndexByte(lw.pending.Bytes(), '\n'); lastNewLine >= 0 {
+		if _, err = Bypass().Write(lw.pending.Next(lastNewLine + 1)); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Flush writes the pending incomplete line, if any, ending it with a new line.
+func (lw *lineWriter) Flush() {
+	defer lw.access.Unlock()
+	lw.access.Lock()
+	if lw.pending.Len() == 0 {
+		return
+	}
+	lw.pending.WriteByte('\n')
+	_, _ = Bypass().Write(lw.pending.Bytes())
+	lw.pending.Reset()
+}
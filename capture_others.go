@@ -0,0 +1,39 @@
+//go:build !unix
+
+package liveprogress
+
+import (
+	"fmt"
+	"os"
+)
+
+// redirection replaces a standard output variable by the write end of a pipe.
+type redirection struct {
+	target   **os.File
+	captured *os.File // the standard output
+	original *os.File // same as captured: its file descriptor is left untouched
+	reader   *os.File
+	writer   *os.File
+}
+
+func redirect(target **os.File) (r *redirection, err error) {
+	r = &redirection{
+		target:   target,
+		captured: *target,
+		original: *target,
+	}
+	if r.reader, r.writer, err = os.Pipe(); err != nil {
+		return nil, fmt.Errorf("failed to create pipe for %s: %w", r.captured.Name(), err)
+	}
+	*target = r.writer
+	return
+}
+
+// restore sets the standard output variable back to its original value and closes the pipe write end.
+func (r *redirection) restore() {
+	*r.target = r.captured
+	r.writer.Close()
+}
+
+// release does nothing: the original file is the standard output itself.
+func (r *redirection) release() {}
@@ -0,0 +1,69 @@
+package liveprogress_test
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+)
+
+// sameStdout returns true if os.Stdout is the same file as original.
+func sameStdout(t *testing.T, original os.FileInfo) bool {
+	t.Helper()
+	current, err := os.Stdout.Stat()
+	if err != nil {
+		t.Fatalf("failed to stat standard output: %s", err)
+	}
+	return os.SameFile(original, current)
+}
+
+func TestCaptureStdio(t *testing.T) {
+	stdout, err := os.Stdout.Stat()
+	if err != nil {
+		t.Fatalf("failed to stat standard output: %s", err)
+	}
+	logOutput, logFlags := log.Writer(), log.Flags()
+	t.Cleanup(func() { log.SetFlags(logFlags) })
+	log.SetFlags(0)
+	t.Cleanup(liveprogress.DisableCaptureStdio)
+	liveprogress.CaptureStdio()
+	harness := liveprogresstest.New(t, 30, 6)
+	if sameStdout(t, stdout) {
+		t.Fatal("standard output should be captured while running")
+	}
+	liveprogress.AddCustomLine(func() string { return "live line" })
+	harness.Step()
+	fmt.Println("to stdout")
+	fmt.Fprintln(os.Stderr, "to stderr")
+	log.Print("from log")
+	fmt.Print("incomplete")
+	if err = liveprogress.Stop(false); err != nil {
+		t.Fatalf("failed to stop: %s", err)
+	}
+	screen := harness.Screen()
+	for _, line := range []string{"to stdout\n", "to stderr\n", "from log\n", "incomplete\n"} {
+		if !strings.Contains(screen, line) {
+			t.Errorf("captured line %q not written above the live area:\n%s", line, screen)
+		}
+	}
+	if !strings.HasSuffix(screen, "live line") {
+		t.Errorf("live area should stay last:\n%s", screen)
+	}
+	// restored
+	if !sameStdout(t, stdout) {
+		t.Error("standard output should be restored after Stop()")
+	}
+	if log.Writer() != logOutput {
+		t.Error("log package output should be restored after Stop()")
+	}
+	// disabled
+	liveprogress.DisableCaptureStdio()
+	liveprogresstest.New(t, 30, 6)
+	if !sameStdout(t, stdout) {
+		t.Error("standard output should not be captured once the capture is disabled")
+	}
+}
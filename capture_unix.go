@@ -0,0 +1,51 @@
+//go:build unix
+
+package liveprogress
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// redirection replaces a standard output file descriptor by the write end of a pipe.
+type redirection struct {
+	captured *os.File // the standard output (its file descriptor now points to the pipe)
+	original *os.File // a duplicate of the original file descriptor
+	reader   *os.File
+	writer   *os.File
+}
+
+func redirect(target **os.File) (r *redirection, err error) {
+	r = &redirection{
+		captured: *target,
+	}
+	originalFd, err := unix.Dup(int(r.captured.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to duplicate %s: %w", r.captured.Name(), err)
+	}
+	r.original = os.NewFile(uintptr(originalFd), r.captured.Name())
+	if r.reader, r.writer, err = os.Pipe(); err != nil {
+		r.original.Close()
+		return nil, fmt.Errorf("failed to create pipe for %s: %w", r.captured.Name(), err)
+	}
+	if err = unix.Dup2(int(r.writer.Fd()), int(r.captured.Fd())); err != nil {
+		r.original.Close()
+		r.reader.Close()
+		r.writer.Close()
+		return nil, fmt.Errorf("failed to redirect %s: %w", r.captured.Name(), err)
+	}
+	return
+}
+
+// restore points the standard output file descriptor to its original destination again and closes the pipe write end.
+func (r *redirection) restore() {
+	_ = unix.Dup2(int(r.original.Fd()), int(r.captured.Fd()))
+	r.writer.Close()
+}
+
+// release closes the duplicate of the original file descriptor.
+func (r *redirection) release() {
+	r.original.Close()
+}
@@ -0,0 +1,47 @@
+package liveprogress
+
+import (
+	"time"
+)
+
+// Clock is the time source used by liveprogress: by the refresh loop (see DefaultClock), by bars (see WithClock())
+// and by spinners (see WithSpinnerClock()). Replace it to render time based decorators deterministically,
+// for example with the liveprogresstest package fake clock.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker is the ticker returned by a Clock, see time.Ticker.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock returns the Clock based on the system wall clock (time package). This is the default clock.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	*time.Ticker
+}
+
+func (st systemTicker) C() <-chan time.Time {
+	return st.Ticker.C
+}
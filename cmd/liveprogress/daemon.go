@@ -0,0 +1,310 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogressipc"
+)
+
+const (
+	dialTimeout = 2 * time.Second
+)
+
+// subcommands drive a daemon owning the terminal from shell scripts:
+//
+//	liveprogress daemon --title "Installing" &
+//	liveprogress bar add --name deps --total 10
+//	liveprogress bar inc deps
+//	liveprogress log "deps installed"
+//	liveprogress stop
+var subcommands = map[string]func(args []string) error{
+	"daemon": runDaemon,
+	"bar":    runBar,
+	"log":    runLog,
+	"stop":   runStop,
+}
+
+const subcommandsUsage = `Daemon mode:
+  %[1]s daemon [--socket path] [--title text]        draw the bars of the subcommands below on standard error
+  %[1]s bar add [--socket path] --name name [--total n] [--group group]
+  %[1]s bar inc|set [--socket path] name [n]           add n (default 1) to or set the current value of a bar
+  %[1]s bar done|abort|remove [--socket path] name
+  %[1]s log [--socket path] [text...]                  write text (or standard input lines) above the bars
+  %[1]s stop [--socket path]                           stop the daemon, leaving its last frame on screen
+`
+
+// defaultSocket returns the socket path announced by the environment, or a path within the user runtime directory
+// ($XDG_RUNTIME_DIR) or within a private per user directory of the temporary directory (see privateSocketDir()).
+func defaultSocket() string {
+	if path := os.Getenv(liveprogressipc.EnvSocket); path != "" {
+		return path
+	}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "liveprogress.sock")
+	}
+	return filepath.Join(privateSocketDir(), "daemon.sock")
+}
+
+// privateSocketDir returns the per user directory of the default socket when there is no user runtime directory.
+// As it lives within the shared temporary directory, its owner and mode are checked before use, see checkSocketDir().
+func privateSocketDir() string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("liveprogress-%d", os.Getuid()))
+}
+
+// checkSocketDir creates (if create is true) and checks the private directory of the socket at path, if path is within it:
+// it must be a real directory owned by the current user and not accessible by others.
+func checkSocketDir(path string, create bool) error {
+	dir := privateSocketDir()
+	if filepath.Dir(path) != dir {
+		return nil
+	}
+	if create {
+		if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("failed to create socket directory: %w", err)
+		}
+	}
+	infos, err := os.Lstat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat socket directory: %w", err)
+	}
+	if !infos.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if infos.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %s)", dir, infos.Mode().Perm())
+	}
+	return checkOwner(dir, infos)
+}
+
+// checkSocket checks that the socket at path, if it exists, belongs to the current user.
+func checkSocket(path string) (exists bool, err error) {
+	infos, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat socket: %w", err)
+	}
+	if infos.Mode().Type() != os.ModeSocket {
+		return true, fmt.Errorf("%s is not a socket", path)
+	}
+	return true, checkOwner(path, infos)
+}
+
+func checkOwner(path string, infos os.FileInfo) error {
+	if uid, ok := fileOwner(infos); ok && uid != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user (uid %d)", path, uid)
+	}
+	return nil
+}
+
+func newFlagSet(name string) (fs *flag.FlagSet, socket *string) {
+	fs = flag.NewFlagSet(name, flag.ExitOnError)
+	socket = fs.String("socket", defaultSocket(), "`path` of the daemon Unix socket")
+	return
+}
+
+/*
+	Daemon
+*/
+
+func runDaemon(args []string) (err error) {
+	fs, socket := newFlagSet("daemon")
+	title := fs.String("title", "", "`text` shown under the bars")
+	_ = fs.Parse(args)
+	if err = checkSocketDir(*socket, true); err != nil {
+		return
+	}
+	if err = removeStaleSocket(*socket); err != nil {
+		return
+	}
+	var (
+		stopped  = make(chan struct{})
+		stopOnce sync.Once
+		stop     = func() { stopOnce.Do(func() { close(stopped) }) }
+	)
+	server, err := liveprogressipc.Listen(*socket,
+		liveprogressipc.WithSharedBars(),
+		liveprogressipc.WithStopHandler(stop),
+		liveprogressipc.WithBarOptions(daemonBarOptions),
+	)
+	if err != nil {
+		return
+	}
+	defer server.Close()
+	liveprogress.Output = os.Stderr
+	if err = liveprogress.Start(); err != nil {
+		return fmt.Errorf("failed to start live progress: %w", err)
+	}
+	if *title != "" {
+		spinner := liveprogress.NewSpinner()
+		liveprogress.SetMainLineAsCustomLine(func() string {
+			return spinner.String() + " " + *title
+		})
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	select {
+	case <-stopped:
+	case <-signals:
+	}
+	// the stop client is released by server.Close(), once the last frame is drawn
+	return liveprogress.Stop(false)
+}
+
+// removeStaleSocket removes the socket file left by a daemon which did not stop properly.
+// Sockets of other users are neither dialed nor removed.
+func removeStaleSocket(path string) error {
+	if exists, err := checkSocket(path); !exists || err != nil {
+		return err
+	}
+	if client, err := liveprogressipc.Dial(path); err == nil {
+		client.Close()
+		return fmt.Errorf("a daemon is already listening on %s", path)
+	}
+	return os.Remove(path)
+}
+
+func daemonBarOptions(spec liveprogressipc.BarSpec) (opts []liveprogress.BarOption) {
+	if spec.Name != "" {
+		name := spec.Name + " "
+		opts = append(opts, liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string { return name }))
+	}
+	return append(opts,
+		liveprogress.WithPrependPercent(liveprogress.BaseStyle()),
+		liveprogress.WithAppendDecorator(func(pb *liveprogress.Bar) string {
+			return fmt.Sprintf(" %d/%d", pb.Current(), pb.Total())
+		}),
+		liveprogress.WithAppendTimeRemaining(liveprogress.BaseStyle()),
+	)
+}
+
+/*
+	Clients
+*/
+
+// dial connects to the daemon, retrying for a while as it may just have been started in the background.
+func dial(path string) (client *liveprogressipc.Client, err error) {
+	deadline := time.Now().Add(dialTimeout)
+	for {
+		if client, err = dialOwned(path); err == nil || time.Now().After(deadline) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// dialOwned connects to the daemon after checking that its socket belongs to the current user.
+func dialOwned(path string) (client *liveprogressipc.Client, err error) {
+	if err = checkSocketDir(path, false); err != nil {
+		return
+	}
+	if _, err = checkSocket(path); err != nil {
+		return
+	}
+	return liveprogressipc.Dial(path)
+}
+
+func runBar(args []string) (err error) {
+	if len(args) == 0 {
+		return errors.New("missing bar action: add, inc, set, done, abort or remove")
+	}
+	action := args[0]
+	fs, socket := newFlagSet("bar " + action)
+	var (
+		name  string
+		group string
+		total uint64
+	)
+	if action == "add" {
+		fs.StringVar(&name, "name", "", "`name` of the bar, used by the other bar actions")
+		fs.StringVar(&group, "group", "", "`group` of the bar")
+		fs.Uint64Var(&total, "total", liveprogress.DefaultTotal, "total `value` of the bar")
+	}
+	_ = fs.Parse(args[1:])
+	// validate before connecting
+	var value uint64
+	switch action {
+	case "add":
+		if name == "" {
+			return errors.New("missing --name")
+		}
+	case "inc", "set", "done", "abort", "remove":
+		if fs.NArg() == 0 {
+			return errors.New("missing bar name")
+		}
+		name = fs.Arg(0)
+		if action == "inc" {
+			value = 1
+		}
+		if (action == "inc" || action == "set") && fs.NArg() > 1 {
+			if value, err = strconv.ParseUint(fs.Arg(1), 10, 64); err != nil {
+				return fmt.Errorf("invalid value: %w", err)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown bar action %q", action)
+	}
+	client, err := dial(*socket)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	bar := client.Bar(name)
+	switch action {
+	case "add":
+		client.AddBar(liveprogressipc.WithKey(name), liveprogressipc.WithName(name),
+			liveprogressipc.WithGroup(group), liveprogressipc.WithTotal(total))
+	case "inc":
+		bar.CurrentAdd(value)
+	case "set":
+		bar.CurrentSet(value)
+	case "done":
+		bar.Complete()
+	case "abort":
+		bar.Abort()
+	case "remove":
+		bar.Remove()
+	}
+	return client.Err()
+}
+
+func runLog(args []string) (err error) {
+	fs, socket := newFlagSet("log")
+	_ = fs.Parse(args)
+	client, err := dial(*socket)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	if fs.NArg() > 0 {
+		_, err = fmt.Fprintln(client.Bypass(), strings.Join(fs.Args(), " "))
+		return
+	}
+	_, err = io.Copy(client.Bypass(), os.Stdin)
+	return
+}
+
+func runStop(args []string) (err error) {
+	fs, socket := newFlagSet("stop")
+	_ = fs.Parse(args)
+	client, err := dialOwned(*socket)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	return client.Stop()
+}
@@ -0,0 +1,256 @@
+// Command liveprogress is a pipe viewer: it copies its standard input to its standard output
+// while drawing a progress bar (or a counter if the total size is unknown) on its standard error.
+//
+//	tar c dir | liveprogress -s 10G -N archive | zstd > out.tar.zst
+//
+// When standard error is not a terminal, a progress line is printed periodically instead.
+//
+// It can also run as a daemon owning the terminal, driven by subcommands from shell scripts:
+//
+//	liveprogress daemon &
+//	liveprogress bar add --name deps --total 10
+//	liveprogress bar inc deps
+//	liveprogress log "deps installed"
+//	liveprogress stop
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/mattn/go-isatty"
+)
+
+const (
+	nonTTYWidth = 80
+)
+
+var (
+	copyDone atomic.Bool
+	// flags
+	size     string
+	sizeOf   string
+	lines    bool
+	name     string
+	interval time.Duration
+)
+
+func main() {
+	if len(os.Args) > 1 {
+		if subcommand, found := subcommands[os.Args[1]]; found {
+			if err := subcommand(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "liveprogress %s: %s\n", os.Args[1], err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	flag.StringVar(&size, "s", "", "total `size` of the data (e.g. 512M, 10G, 1.5TiB), enables the bar and ETA")
+	flag.StringVar(&size, "size", "", "total `size` of the data, see -s")
+	flag.StringVar(&sizeOf, "S", "", "use the size of `file` as total size (its number of lines with -l)")
+	flag.StringVar(&sizeOf, "size-of", "", "use the size of `file` as total size, see -S")
+	flag.BoolVar(&lines, "l", false, "count lines instead of bytes (the total size is then a number of lines)")
+	flag.BoolVar(&lines, "lines", false, "count lines instead of bytes, see -l")
+	flag.StringVar(&name, "N", "", "`name` shown in front of the progress")
+	flag.StringVar(&name, "name", "", "`name` shown in front of the progress, see -N")
+	flag.DurationVar(&interval, "i", time.Second, "progress lines `interval` when standard error is not a terminal")
+	flag.DurationVar(&interval, "interval", time.Second, "progress lines `interval` when standard error is not a terminal, see -i")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] < input > output\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\n"+subcommandsUsage, os.Args[0])
+	}
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "liveprogress: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() (err error) {
+	// Total
+	total, err := getTotal()
+	if err != nil {
+		return
+	}
+	unit := "B"
+	if lines {
+		unit = " lines"
+	}
+	// Progress: the bar tracks the values (and rate), the line replaces it if the total is unknown
+	liveprogress.Output = os.Stderr
+	interactive := isatty.IsTerminal(os.Stderr.Fd()) || isatty.IsCygwinTerminal(os.Stderr.Fd())
+	if interactive {
+		if err = liveprogress.Start(); err != nil {
+			return fmt.Errorf("failed to start live progress: %w", err)
+		}
+	}
+	var (
+		bar  *liveprogress.Bar
+		item fmt.Stringer
+	)
+	switch {
+	case total > 0 && interactive:
+		bar = liveprogress.SetMainLineAsBar(barOptions(total, unit)...)
+	case total > 0:
+		bar = liveprogress.NewBar(barOptions(total, unit)...)
+		item = bar
+	case interactive:
+		bar = liveprogress.NewBar()
+		liveprogress.SetMainLineAsCustomLine(counterLine(bar, unit))
+	default:
+		bar = liveprogress.NewBar()
+		item = liveprogress.NewCustomLine(counterLine(bar, unit))
+	}
+	if !interactive {
+		stop := make(chan struct{})
+		done := make(chan struct{})
+		go printPeriodically(item, stop, done)
+		defer func() {
+			close(stop)
+			<-done
+		}()
+	}
+	// Copy
+	_, err = io.Copy(&progressWriter{dest: os.Stdout, lines: lines, bar: bar}, os.Stdin)
+	copyDone.Store(true)
+	if interactive {
+		if stopErr := liveprogress.Stop(false); stopErr != nil && err == nil {
+			err = stopErr
+		}
+	}
+	return
+}
+
+func getTotal() (total uint64, err error) {
+	switch {
+	case sizeOf != "" && lines:
+		return countLines(sizeOf)
+	case sizeOf != "":
+		var infos os.FileInfo
+		if infos, err = os.Stat(sizeOf); err != nil {
+			return 0, fmt.Errorf("failed to stat size file: %w", err)
+		}
+		return uint64(infos.Size()), nil
+	case size != "":
+		if lines {
+			return parseSize(size, 1000)
+		}
+		return parseSize(size, 1024)
+	default:
+		return 0, nil
+	}
+}
+
+// countLines returns the number of lines of the file at path, counted as the input lines are (see progressWriter).
+func countLines(path string) (count uint64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open size file: %w", err)
+	}
+	defer file.Close()
+	count, err = countNewLines(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count size file lines: %w", err)
+	}
+	return
+}
+
+func countNewLines(reader io.Reader) (count uint64, err error) {
+	buf := make([]byte, 64*1024)
+	for {
+		n, readErr := reader.Read(buf)
+		count += uint64(bytes.Count(buf[:n], []byte{'\n'}))
+		if readErr == io.EOF {
+			return count, nil
+		}
+		if readErr != nil {
+			return count, readErr
+		}
+	}
+}
+
+/*
+	Progress items
+*/
+
+func barOptions(total uint64, unit string) (opts []liveprogress.BarOption) {
+	if name != "" {
+		opts = append(opts, liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string {
+			return name + ": "
+		}))
+	}
+	return append(opts,
+		liveprogress.WithTotal(total),
+		liveprogress.WithPrependPercent(liveprogress.BaseStyle()),
+		liveprogress.WithAppendDecorator(func(pb *liveprogress.Bar) string {
+			return fmt.Sprintf(" %s/%s [%s/s]",
+				formatValue(pb.Current(), unit), formatValue(total, unit), formatValue(uint64(pb.Rate()), unit))
+		}),
+		liveprogress.WithAppendTimeRemaining(liveprogress.BaseStyle()),
+	)
+}
+
+// counterLine returns the generator of the line shown instead of the bar when the total is unknown.
+func counterLine(bar *liveprogress.Bar, unit string) func() string {
+	spinner := liveprogress.NewSpinner()
+	return func() string {
+		var builder strings.Builder
+		if name != "" {
+			builder.WriteString(name + ": ")
+		}
+		if !copyDone.Load() {
+			builder.WriteString(spinner.String() + " ")
+		}
+		builder.WriteString(formatValue(bar.Current(), unit))
+		builder.WriteString(" [" + formatValue(uint64(bar.Rate()), unit) + "/s] ")
+		builder.WriteString(time.Since(bar.GetCreationTime()).Round(time.Second).String())
+		return builder.String()
+	}
+}
+
+func printPeriodically(item fmt.Stringer, stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	print := func() {
+		fmt.Fprintln(os.Stderr, strings.Join(liveprogress.RenderItems(nonTTYWidth, item), "\n"))
+	}
+	for {
+		select {
+		case <-ticker.C:
+			print()
+		case <-stop:
+			print()
+			return
+		}
+	}
+}
+
+/*
+	Copy
+*/
+
+// progressWriter writes to dest and reports the number of bytes (or lines) written to bar.
+type progressWriter struct {
+	dest  io.Writer
+	lines bool
+	bar   *liveprogress.Bar
+}
+
+func (pw *progressWriter) Write(p []byte) (n int, err error) {
+	n, err = pw.dest.Write(p)
+	if pw.lines {
+		pw.bar.CurrentAdd(uint64(bytes.Count(p[:n], []byte{'\n'})))
+	} else {
+		pw.bar.CurrentAdd(uint64(n))
+	}
+	return
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package main
+
+import (
+	"os"
+)
+
+// fileOwner returns the user id owning the file described by info. Not available on this platform.
+func fileOwner(info os.FileInfo) (uid int, ok bool) {
+	return
+}
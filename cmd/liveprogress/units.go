@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	unitPrefixes = []string{"", "K", "M", "G", "T", "P", "E"}
+)
+
+// parseSize parses a size such as "512", "10G", "1.5TiB" or "2k". base is 1024 for bytes and 1000 for lines.
+func parseSize(size string, base float64) (value uint64, err error) {
+	number := strings.TrimSpace(size)
+	number = strings.TrimSuffix(strings.TrimSuffix(number, "B"), "b")
+	number = strings.TrimSuffix(number, "i")
+	multiplier := 1.0
+	if len(number) > 0 {
+		suffix := strings.ToUpper(number[len(number)-1:])
+		for index, prefix := range unitPrefixes[1:] {
+			if suffix == prefix {
+				number = number[:len(number)-1]
+				for i := 0; i <= index; i++ {
+					multiplier *= base
+				}
+				break
+			}
+		}
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || parsed < 0 || math.IsNaN(parsed) {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	// float64(math.MaxUint64) is rounded up to 2^64: anything reaching it (including +Inf) overflows
+	if parsed *= multiplier; parsed >= math.MaxUint64 {
+		return 0, fmt.Errorf("size %q is too large", size)
+	}
+	return uint64(parsed), nil
+}
+
+// formatValue formats value with binary prefixes for bytes ("B" unit) and decimal prefixes otherwise.
+func formatValue(value uint64, unit string) string {
+	base := 1000.0
+	if unit == "B" {
+		base = 1024
+	}
+	scaled := float64(value)
+	index := 0
+	for scaled >= base && index < len(unitPrefixes)-1 {
+		scaled /= base
+		index++
+	}
+	if index == 0 {
+		return fmt.Sprintf("%d%s", value, unit)
+	}
+	prefix := unitPrefixes[index]
+	if unit == "B" {
+		prefix += "i"
+	}
+	return fmt.Sprintf("%.1f%s%s", scaled, prefix, unit)
+}
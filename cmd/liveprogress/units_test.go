@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for _, tc := range []struct {
+		size     string
+		base     float64
+		expected uint64
+	}{
+		{"512", 1024, 512},
+		{"2k", 1024, 2048},
+		{"10G", 1024, 10 << 30},
+		{"1.5TiB", 1024, 3 << 39},
+		{" 4 MB ", 1024, 4 << 20},
+		{"2k", 1000, 2000},
+		{"1.5M", 1000, 1500000},
+		{"15E", 1024, 15 << 60},
+	} {
+		value, err := parseSize(tc.size, tc.base)
+		if err != nil {
+			t.Errorf("failed to parse %q: %s", tc.size, err)
+			continue
+		}
+		if value != tc.expected {
+			t.Errorf("parsing %q (base %g): expected %d, got %d", tc.size, tc.base, tc.expected, value)
+		}
+	}
+	for _, invalid := range []string{"", "G", "-1", "ten", "10X", "NaN", "inf", "+Inf", "-inf", "1e30", "16E", "18446744073709551616"} {
+		if _, err := parseSize(invalid, 1024); err == nil {
+			t.Errorf("parsing %q should have failed", invalid)
+		}
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tc := range []struct {
+		value    uint64
+		unit     string
+		expected string
+	}{
+		{0, "B", "0B"},
+		{1023, "B", "1023B"},
+		{1024, "B", "1.0KiB"},
+		{1536 << 20, "B", "1.5GiB"},
+		{999, " lines", "999 lines"},
+		{12345, " lines", "12.3K lines"},
+		{1 << 63, "B", "8.0EiB"},
+	} {
+		if formatted := formatValue(tc.value, tc.unit); formatted != tc.expected {
+			t.Errorf("formatting %d%s: expected %q, got %q", tc.value, tc.unit, tc.expected, formatted)
+		}
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "lines")
+	if err := os.WriteFile(file, []byte("one\ntwo\nthree"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	count, err := countLines(file)
+	if err != nil {
+		t.Fatalf("failed to count lines: %s", err)
+	}
+	// as the input lines are counted: the unterminated last line is not
+	if count != 2 {
+		t.Errorf("expected 2 lines, got %d", count)
+	}
+}
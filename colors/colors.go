@@ -10,8 +10,10 @@ import (
 */
 
 func init() {
-	// oportunistic init (default liveterm.Output value, eg os.Stdout)
+	// oportunistic init (default liveprogress.Output value, eg os.Stdout)
 	Generate()
+	// keep the styles in sync with the profile overrides
+	liveprogress.OnColorProfileChange(Generate)
 }
 
 var (
@@ -19,12 +21,14 @@ var (
 )
 
 // Generate (re)generates all the styles with the current terminal profile.
-// Call this function after liveprogress.Start() if you have changed the default liveprogress.Output value, otherwise no need to call it.
+// Call this function after liveprogress.Start() if you have changed the default liveprogress.Output value, otherwise no need
+// to call it: liveprogress.SetColorProfile() and liveprogress.ResetColorProfile() already regenerate the styles.
 func Generate() {
 	NoColor = liveprogress.BaseStyle()
 	generateANSIBasic()
 	generateANSIExtended()
 	generateANSIExtendedGreyscale()
+	generateThemes()
 }
 
 /*
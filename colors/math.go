@@ -0,0 +1,99 @@
+package colors
+
+import (
+	"math"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+)
+
+/*
+	Color math: HSL/HSV adjustments and blending
+	All colors are hex-encoded, e.g. "#abcdef". Invalid colors generate the NoColor style.
+	Styles are generated with RGB(): see its documentation about when to call these functions.
+*/
+
+// Lighten generates a style with a foreground color set to rgb with its HSL lightness increased by amount (between 0 and 1).
+func Lighten(rgb string, amount float64) termenv.Style {
+	return adjustHSL(rgb, func(h, s, l float64) (float64, float64, float64) {
+		return h, s, l + amount
+	})
+}
+
+// Darken generates a style with a foreground color set to rgb with its HSL lightness decreased by amount (between 0 and 1).
+func Darken(rgb string, amount float64) termenv.Style {
+	return adjustHSL(rgb, func(h, s, l float64) (float64, float64, float64) {
+		return h, s, l - amount
+	})
+}
+
+// Saturate generates a style with a foreground color set to rgb with its HSL saturation increased by amount (between -1 and 1).
+// Use a negative amount to desaturate.
+func Saturate(rgb string, amount float64) termenv.Style {
+	return adjustHSL(rgb, func(h, s, l float64) (float64, float64, float64) {
+		return h, s + amount, l
+	})
+}
+
+// Complement generates a style with a foreground color set to the complementary color of rgb (opposite hue).
+func Complement(rgb string) termenv.Style {
+	return adjustHSL(rgb, func(h, s, l float64) (float64, float64, float64) {
+		return h + 180, s, l
+	})
+}
+
+// Blend generates a style with a foreground color set to the mix of a and b. t (between 0 and 1) is the ratio of b:
+// 0 gives a, 1 gives b. Blending is done in the CIE L*a*b* color space for perceptually even steps.
+func Blend(a, b string, t float64) termenv.Style {
+	colorA, err := colorful.Hex(a)
+	if err != nil {
+		return NoColor
+	}
+	colorB, err := colorful.Hex(b)
+	if err != nil {
+		return NoColor
+	}
+	return RGB(colorA.BlendLab(colorB, clamp(t)).Clamped().Hex())
+}
+
+// FromHSL generates a style with a foreground color set from its hue (in degrees), saturation and lightness (between 0 and 1).
+func FromHSL(h, s, l float64) termenv.Style {
+	return RGB(colorful.Hsl(normalizeHue(h), clamp(s), clamp(l)).Clamped().Hex())
+}
+
+// FromHSV generates a style with a foreground color set from its hue (in degrees), saturation and value (between 0 and 1).
+func FromHSV(h, s, v float64) termenv.Style {
+	return RGB(colorful.Hsv(normalizeHue(h), clamp(s), clamp(v)).Clamped().Hex())
+}
+
+// WithBarColor sets the style of a progress bar to rgb and the style of its empty part to a dimmed version of rgb.
+func WithBarColor(rgb string) liveprogress.BarOption {
+	return func(pb *liveprogress.Bar) {
+		liveprogress.WithBarStyle(RGB(rgb))(pb)
+		liveprogress.WithBarEmptyStyle(adjustHSL(rgb, func(h, s, l float64) (float64, float64, float64) {
+			return h, s * 0.5, l * 0.6
+		}))(pb)
+	}
+}
+
+func adjustHSL(rgb string, adjust func(h, s, l float64) (float64, float64, float64)) termenv.Style {
+	color, err := colorful.Hex(rgb)
+	if err != nil {
+		return NoColor
+	}
+	h, s, l := adjust(color.Hsl())
+	return FromHSL(h, s, l)
+}
+
+func normalizeHue(h float64) float64 {
+	h = math.Mod(h, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func clamp(value float64) float64 {
+	return math.Max(0, math.Min(1, value))
+}
@@ -0,0 +1,23 @@
+package colors
+
+import (
+	"log/slog"
+
+	"github.com/muesli/termenv"
+)
+
+/*
+	slog levels styles
+*/
+
+// SlogLevelStyles returns a set of level styles to use with liveprogress.SlogHandlerOptions.LevelStyles.
+// As the others styles of this package, the returned styles are generated with the current terminal profile:
+// call it after liveprogress.Start() if you have changed the default liveprogress.Output value.
+func SlogLevelStyles() map[slog.Level]termenv.Style {
+	return map[slog.Level]termenv.Style{
+		slog.LevelDebug: ANSIBasicBrightBlack,
+		slog.LevelInfo:  ANSIBasicBlue,
+		slog.LevelWarn:  ANSIBasicYellow.Bold(),
+		slog.LevelError: ANSIBasicRed.Bold(),
+	}
+}
@@ -0,0 +1,215 @@
+package colors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/lucasb-eyer/go-colorful"
+	"github.com/muesli/termenv"
+)
+
+/*
+	Themes: semantic styles for bars and decorators
+*/
+
+var (
+	DarkTheme  Theme // DarkTheme is the built-in theme for terminals with a dark background, generated from DarkThemeSpec.
+	LightTheme Theme // LightTheme is the built-in theme for terminals with a light background, generated from LightThemeSpec.
+)
+
+var (
+	// DarkThemeSpec is the specification of the built-in dark theme.
+	DarkThemeSpec = ThemeSpec{
+		Bar:      StyleSpec{Foreground: "75"},
+		BarEmpty: StyleSpec{Foreground: "238"},
+		Percent:  StyleSpec{Foreground: "75", Bold: true},
+		ETA:      StyleSpec{Foreground: "250"},
+		Label:    StyleSpec{Foreground: "255"},
+		Success:  StyleSpec{Foreground: "78", Bold: true},
+		Error:    StyleSpec{Foreground: "203", Bold: true},
+		Muted:    StyleSpec{Foreground: "244", Faint: true},
+	}
+	// LightThemeSpec is the specification of the built-in light theme.
+	LightThemeSpec = ThemeSpec{
+		Bar:      StyleSpec{Foreground: "25"},
+		BarEmpty: StyleSpec{Foreground: "252"},
+		Percent:  StyleSpec{Foreground: "25", Bold: true},
+		ETA:      StyleSpec{Foreground: "240"},
+		Label:    StyleSpec{Foreground: "235"},
+		Success:  StyleSpec{Foreground: "28", Bold: true},
+		Error:    StyleSpec{Foreground: "160", Bold: true},
+		Muted:    StyleSpec{Foreground: "245", Faint: true},
+	}
+)
+
+func generateThemes() {
+	DarkTheme = DarkThemeSpec.Generate()
+	LightTheme = LightThemeSpec.Generate()
+}
+
+// AdaptiveTheme returns either DarkTheme or LightTheme depending on liveprogress.HasDarkBackground().
+// The terminal is queried at each call: keep the returned theme instead of calling it for each bar.
+func AdaptiveTheme() Theme {
+	if liveprogress.HasDarkBackground() {
+		return DarkTheme
+	}
+	return LightTheme
+}
+
+// Theme holds a style for each semantic role of a progress line.
+// Use WithTheme() to apply the bar roles to a bar and the others roles with the decorators of your choice,
+// for example liveprogress.WithAppendPercent(theme.Percent).
+type Theme struct {
+	Bar      termenv.Style // filled part of the bar
+	BarEmpty termenv.Style // empty part of the bar
+	Percent  termenv.Style // percentage decorators
+	ETA      termenv.Style // elapsed and remaining time decorators
+	Label    termenv.Style // names and descriptions
+	Success  termenv.Style // completion glyphs and messages
+	Error    termenv.Style // failure glyphs and messages
+	Muted    termenv.Style // secondary informations
+}
+
+// WithTheme sets the bar and empty bar styles of a progress bar from theme.
+func WithTheme(theme Theme) liveprogress.BarOption {
+	return func(pb *liveprogress.Bar) {
+		liveprogress.WithBarStyle(theme.Bar)(pb)
+		liveprogress.WithBarEmptyStyle(theme.BarEmpty)(pb)
+	}
+}
+
+// ThemeSpec is the serializable specification of a Theme. See LoadThemeFile() to load one from a JSON file.
+type ThemeSpec struct {
+	Bar      StyleSpec `json:"bar"`
+	BarEmpty StyleSpec `json:"barEmpty"`
+	Percent  StyleSpec `json:"percent"`
+	ETA      StyleSpec `json:"eta"`
+	Label    StyleSpec `json:"label"`
+	Success  StyleSpec `json:"success"`
+	Error    StyleSpec `json:"error"`
+	Muted    StyleSpec `json:"muted"`
+}
+
+// Generate builds the theme styles with the current terminal profile.
+// As for others styles of this package, call it after liveprogress.Start() if you have changed the default liveprogress.Output value.
+func (ts ThemeSpec) Generate() Theme {
+	return Theme{
+		Bar:      ts.Bar.Generate(),
+		BarEmpty: ts.BarEmpty.Generate(),
+		Percent:  ts.Percent.Generate(),
+		ETA:      ts.ETA.Generate(),
+		Label:    ts.Label.Generate(),
+		Success:  ts.Success.Generate(),
+		Error:    ts.Error.Generate(),
+		Muted:    ts.Muted.Generate(),
+	}
+}
+
+// StyleSpec is the serializable specification of a style.
+// Colors are either hex-encoded ("#abcdef") or an ANSI color index ("0" to "255"). An empty color is not set.
+type StyleSpec struct {
+	Foreground string `json:"fg,omitempty"`
+	Background string `json:"bg,omitempty"`
+	Bold       bool   `json:"bold,omitempty"`
+	Faint      bool   `json:"faint,omitempty"`
+	Italic     bool   `json:"italic,omitempty"`
+	Underline  bool   `json:"underline,omitempty"`
+}
+
+// Generate builds the style with the current terminal profile. Invalid colors (see Validate()) are not set.
+func (ss StyleSpec) Generate() (style termenv.Style) {
+	profile := liveprogress.GetTermProfile()
+	style = liveprogress.BaseStyle().Foreground(specColor(profile, ss.Foreground)).Background(specColor(profile, ss.Background))
+	if ss.Bold {
+		style = style.Bold()
+	}
+	if ss.Faint {
+		style = style.Faint()
+	}
+	if ss.Italic {
+		style = style.Italic()
+	}
+	if ss.Underline {
+		style = style.Underline()
+	}
+	return
+}
+
+// Validate returns an error if one of the colors can not be parsed.
+func (ss StyleSpec) Validate() error {
+	for _, color := range []string{ss.Foreground, ss.Background} {
+		if color != "" && !validColor(color) {
+			return fmt.Errorf("invalid color %q", color)
+		}
+	}
+	return nil
+}
+
+// validColor returns true if color is hex-encoded or an ANSI color index between 0 and 255.
+func validColor(color string) bool {
+	if strings.HasPrefix(color, "#") {
+		_, err := colorful.Hex(color)
+		return err == nil
+	}
+	index, err := strconv.Atoi(color)
+	return err == nil && index >= 0 && index <= 255
+}
+
+// specColor returns color converted to profile, or nil if it is empty or invalid.
+func specColor(profile termenv.Profile, color string) termenv.Color {
+	if !validColor(color) {
+		return nil
+	}
+	return profile.Color(color)
+}
+
+// LoadTheme reads a JSON encoded ThemeSpec from r and generates its Theme.
+// Roles missing from the JSON document are taken from the built-in theme matching the terminal background.
+func LoadTheme(r io.Reader) (theme Theme, err error) {
+	return loadTheme(r, liveprogress.HasDarkBackground())
+}
+
+// loadTheme is LoadTheme() with the built-in theme to complete the JSON document chosen by dark.
+func loadTheme(r io.Reader, dark bool) (theme Theme, err error) {
+	spec := LightThemeSpec
+	if dark {
+		spec = DarkThemeSpec
+	}
+	if err = json.NewDecoder(r).Decode(&spec); err != nil {
+		err = fmt.Errorf("failed to decode theme: %w", err)
+		return
+	}
+	for role, style := range map[string]StyleSpec{
+		"bar":      spec.Bar,
+		"barEmpty": spec.BarEmpty,
+		"percent":  spec.Percent,
+		"eta":      spec.ETA,
+		"label":    spec.Label,
+		"success":  spec.Success,
+		"error":    spec.Error,
+		"muted":    spec.Muted,
+	} {
+		if err = style.Validate(); err != nil {
+			err = fmt.Errorf("theme role %q: %w", role, err)
+			return
+		}
+	}
+	theme = spec.Generate()
+	return
+}
+
+// LoadThemeFile reads a JSON encoded ThemeSpec from the file at path and generates its Theme. See LoadTheme().
+func LoadThemeFile(path string) (theme Theme, err error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		err = fmt.Errorf("failed to open theme file: %w", err)
+		return
+	}
+	defer fd.Close()
+	return LoadTheme(fd)
+}
@@ -0,0 +1,81 @@
+package colors
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/muesli/termenv"
+)
+
+// useProfile sets the liveprogress color profile for the duration of the test.
+func useProfile(t *testing.T, profile termenv.Profile) {
+	t.Cleanup(liveprogress.ResetColorProfile)
+	liveprogress.SetColorProfile(profile)
+}
+
+// assertStyle marks the test as failed if style does not render as expected.
+func assertStyle(t *testing.T, role string, style, expected termenv.Style) {
+	t.Helper()
+	if got, want := style.Styled(role), expected.Styled(role); got != want {
+		t.Errorf("unexpected %s style: got %q, expected %q", role, got, want)
+	}
+}
+
+func TestLoadThemeInvalid(t *testing.T) {
+	useProfile(t, termenv.ANSI)
+	for _, document := range []string{
+		`{"bar":{"fg":"300"}}`,
+		`{"bar":{"fg":"256"}}`,
+		`{"bar":{"fg":"-1"}}`,
+		`{"muted":{"bg":"red"}}`,
+		`{"error":{"fg":"#zzzzzz"}}`,
+		`{"bar":`,
+	} {
+		if _, err := LoadTheme(strings.NewReader(document)); err == nil {
+			t.Errorf("theme %s should be rejected", document)
+		}
+	}
+	for _, document := range []string{
+		`{"bar":{"fg":"0"}}`,
+		`{"bar":{"fg":"255","bg":"#abcdef"}}`,
+		`{}`,
+	} {
+		if _, err := LoadTheme(strings.NewReader(document)); err != nil {
+			t.Errorf("theme %s should be accepted: %s", document, err)
+		}
+	}
+}
+
+func TestLoadThemePartial(t *testing.T) {
+	useProfile(t, termenv.ANSI256)
+	bar := StyleSpec{Foreground: "1", Bold: true}.Generate()
+	for _, test := range []struct {
+		dark bool
+		base Theme
+	}{
+		{dark: true, base: DarkTheme},
+		{dark: false, base: LightTheme},
+	} {
+		theme, err := loadTheme(strings.NewReader(`{"bar":{"fg":"1","bold":true}}`), test.dark)
+		if err != nil {
+			t.Fatalf("failed to load theme: %s", err)
+		}
+		assertStyle(t, "bar", theme.Bar, bar)
+		assertStyle(t, "barEmpty", theme.BarEmpty, test.base.BarEmpty)
+		assertStyle(t, "percent", theme.Percent, test.base.Percent)
+		assertStyle(t, "muted", theme.Muted, test.base.Muted)
+	}
+}
+
+func TestAdaptiveTheme(t *testing.T) {
+	t.Cleanup(func() { liveprogress.Output = os.Stdout })
+	// writers which are not terminals can not be queried: their background is considered black
+	liveprogress.Output = &bytes.Buffer{}
+	useProfile(t, termenv.ANSI256)
+	theme := AdaptiveTheme()
+	assertStyle(t, "bar", theme.Bar, DarkTheme.Bar)
+	assertStyle(t, "label", theme.Label, DarkTheme.Label)
+}
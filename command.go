@@ -0,0 +1,238 @@
+package liveprogress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// CommandOption is a function that can be used to configure a command progress at creation, see Command().
+type CommandOption func(*CommandProgress)
+
+// WithCommandBar sets the options of the command progress bar.
+func WithCommandBar(opts ...BarOption) CommandOption {
+	return func(cp *CommandProgress) {
+		cp.barOpts = append(cp.barOpts, opts...)
+	}
+}
+
+// WithCommandRegexp sets the regular expression used to find the bar current value within the command output lines.
+// The first submatch must be a number (decimals are truncated), for example `(\d+)%` with the default bar total of 100.
+func WithCommandRegexp(re *regexp.Regexp) CommandOption {
+	return func(cp *CommandProgress) {
+		if re == nil {
+			return
+		}
+		cp.parser = func(line string) (current uint64, found bool) {
+			matches := re.FindStringSubmatch(line)
+			if len(matches) < 2 {
+				return
+			}
+			value, err := strconv.ParseFloat(matches[1], 64)
+			if err != nil || value < 0 {
+				return
+			}
+			return uint64(value), true
+		}
+	}
+}
+
+// WithCommandParser sets the function used to find the bar current value within the command output lines.
+// parser is called for each line (stdout and stderr) and must return found as false if the line does not contain a value.
+func WithCommandParser(parser func(line string) (current uint64, found bool)) CommandOption {
+	return func(cp *CommandProgress) {
+		cp.parser = parser
+	}
+}
+
+// WithCommandOutputHidden prevents the command output lines from being written above the live progress.
+// They are still parsed and shown in the status line.
+func WithCommandOutputHidden() CommandOption {
+	return func(cp *CommandProgress) {
+		cp.hideOutput = true
+	}
+}
+
+// CommandProgress tracks the progress of an external command, see Command().
+type CommandProgress struct {
+	cmd *exec.Cmd
+	// config
+	barOpts    []BarOption
+	parser     func(line string) (current uint64, found bool)
+	hideOutput bool
+	// state
+	bar        *Bar
+	status     *CustomLine
+	lastLine   string
+	lastAccess sync.Mutex
+	streams    sync.WaitGroup
+}
+
+// Command prepares the progress tracking of cmd. Once started (see Start() and Run()), the command stdout and stderr
+// (if not already set) are written above the live progress thru Bypass() and their last line is kept in a status line
+// under the command bar. Lines terminated by a carriage return (progress updates of tools such as rsync or curl) are only
+// shown in the status line. Each line is given to the parser (see WithCommandRegexp() and WithCommandParser()) to update the bar.
+// Once the command exits, the status line is removed and the bar is completed on success or aborted on failure.
+func Command(cmd *exec.Cmd, opts ...CommandOption) (cp *CommandProgress) {
+	cp = &CommandProgress{
+		cmd: cmd,
+	}
+	for _, opt := range opts {
+		opt(cp)
+	}
+	return
+}
+
+// Start starts the command and adds its bar and status line to the live progress.
+func (cp *CommandProgress) Start() (err error) {
+	if cp.cmd == nil {
+		return errors.New("command is nil")
+	}
+	if cp.bar != nil {
+		return errors.New("command progress already started")
+	}
+	var readers []io.Reader
+	if cp.cmd.Stdout == nil {
+		stdout, err := cp.cmd.StdoutPipe()
+		if err != nil {
+			return fmt.Errorf("failed to get command stdout: %w", err)
+		}
+		readers = append(readers, stdout)
+	}
+	if cp.cmd.Stderr == nil {
+		stderr, err := cp.cmd.StderrPipe()
+		if err != nil {
+			return fmt.Errorf("failed to get command stderr: %w", err)
+		}
+		readers = append(readers, stderr)
+	}
+	if err = cp.cmd.Start(); err != nil {
+		return
+	}
+	cp.bar = AddBar(cp.barOpts...)
+	cp.status = AddCustomLine(cp.statusLine)
+	for _, reader := range readers {
+		cp.streams.Add(1)
+		go cp.stream(reader)
+	}
+	return
+}
+
+// Wait waits for the command to exit, then completes or aborts its bar depending on its exit code.
+func (cp *CommandProgress) Wait() (err error) {
+	if cp.bar == nil {
+		return errors.New("command progress not started")
+	}
+	// output must be entirely read before calling cmd.Wait()
+	cp.streams.Wait()
+	err = cp.cmd.Wait()
+	RemoveCustomLine(cp.status)
+	if err != nil {
+		cp.bar.Abort()
+	} else {
+		cp.bar.Complete()
+	}
+	return
+}
+
+// Run starts the command and waits for it to exit, see Start() and Wait().
+func (cp *CommandProgress) Run() error {
+	if err := cp.Start(); err != nil {
+		return err
+	}
+	return cp.Wait()
+}
+
+// Bar returns the command progress bar. It is nil until the command is started.
+func (cp *CommandProgress) Bar() *Bar {
+	return cp.bar
+}
+
+// LastLine returns the last line outputted by the command.
+func (cp *CommandProgress) LastLine() string {
+	defer cp.lastAccess.Unlock()
+	cp.lastAccess.Lock()
+	return cp.lastLine
+}
+
+func (cp *CommandProgress) statusLine() string {
+	line := cp.LastLine()
+	if width, _ := termSize(); width > 0 {
+		line = runewidth.Truncate(line, width, "…")
+	}
+	return line
+}
+
+func (cp *CommandProgress) stream(reader io.Reader) {
+	defer cp.streams.Done()
+	var (
+		pending      bytes.Buffer
+		carriage     bool   // last byte was a '\r': a following '\n' makes it a "\r\n" line ending
+		carriageLine string // line handled when the '\r' was read
+		buf          = make([]byte, 4096)
+	)
+	for {
+		n, err := reader.Read(buf)
+		for _, b := range buf[:n] {
+			switch b {
+			case '\n':
+				if carriage {
+					// already handled, it was a final line after all
+					cp.writeLine(carriageLine)
+				} else {
+					cp.handleLine(pending.String())
+					cp.writeLine(pending.String())
+				}
+				pending.Reset()
+				carriage = false
+			case '\r':
+				// handled right away: progress lines are usually followed by silence
+				carriageLine = pending.String()
+				cp.handleLine(carriageLine)
+				pending.Reset()
+				carriage = true
+			default:
+				pending.WriteByte(b)
+				carriage = false
+			}
+		}
+		if err != nil {
+			if pending.Len() > 0 {
+				cp.handleLine(pending.String())
+				cp.writeLine(pending.String())
+			}
+			return
+		}
+	}
+}
+
+// handleLine shows line in the status line and gives it to the parser.
+func (cp *CommandProgress) handleLine(line string) {
+	if line == "" {
+		return
+	}
+	cp.lastAccess.Lock()
+	cp.lastLine = line
+	cp.lastAccess.Unlock()
+	// the status line content has changed
+	Refresh()
+	if cp.parser != nil {
+		if current, found := cp.parser(line); found {
+			cp.bar.CurrentSet(current)
+		}
+	}
+}
+
+// writeLine writes a final line above the live progress, unless the output is hidden.
+func (cp *CommandProgress) writeLine(line string) {
+	if line != "" && !cp.hideOutput {
+		fmt.Fprintln(Bypass(), line)
+	}
+}
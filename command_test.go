@@ -0,0 +1,64 @@
+//go:build unix
+
+package liveprogress_test
+
+import (
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+)
+
+func TestCommand(t *testing.T) {
+	terminal := liveprogresstest.NewTerminal(30, 6)
+	useOutput(t, terminal, func() bool { return true }, terminal.Size)
+	// only explicit refreshes can show the status line in time
+	liveprogress.RefreshInterval = 10 * time.Millisecond
+	liveprogress.RefreshMaxInterval = time.Hour
+	t.Cleanup(func() { liveprogress.RefreshMaxInterval = time.Second })
+	if err := liveprogress.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	defer liveprogress.Stop(true)
+	// the command waits for its standard input to be closed between its progress lines
+	cmd := exec.Command("sh", "-c", `printf 'line one\n10%% first\r'; read _; printf '50%% second\r\n'`)
+	resume, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatalf("failed to get command stdin: %s", err)
+	}
+	cp := liveprogress.Command(cmd,
+		liveprogress.WithCommandRegexp(regexp.MustCompile(`(\d+)%`)),
+		liveprogress.WithCommandBar(liveprogress.WithWidth(10), liveprogress.WithAppendPercent(liveprogress.BaseStyle())),
+	)
+	if err = cp.Start(); err != nil {
+		t.Fatalf("failed to start command: %s", err)
+	}
+	// a line ending with a carriage return is handled without waiting for the next output
+	waitScreen(t, terminal, "10% first")
+	if line := cp.LastLine(); line != "10% first" {
+		t.Errorf("unexpected last line: %q", line)
+	}
+	if current := cp.Bar().Current(); current != 10 {
+		t.Errorf("unexpected bar value: %d", current)
+	}
+	resume.Close()
+	if err = cp.Wait(); err != nil {
+		t.Fatalf("command failed: %s", err)
+	}
+	if line := cp.LastLine(); line != "50% second" {
+		t.Errorf("unexpected last line: %q", line)
+	}
+	liveprogress.Tick()
+	// final lines are written above the bar, carriage return ones only in the status line
+	expected := "line one\n50% second\n[========] 100%"
+	if screen := terminal.String(); screen != expected {
+		t.Errorf("unexpected screen:\n%s\nexpected:\n%s", screen, expected)
+	}
+	if strings.Contains(strings.Join(terminal.Scrollback(), "\n"), "first") {
+		t.Error("carriage return lines should not be written above the bar")
+	}
+}
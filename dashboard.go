@@ -0,0 +1,284 @@
+package liveprogress
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/muesli/ansi"
+)
+
+const (
+	// DashboardLogHistory is the number of Bypass() lines kept to be sent to the dashboard clients when they connect.
+	DashboardLogHistory = 100
+	// defaultDashboardInterval is the dashboard refresh interval when RefreshInterval is 0 (manual refreshes).
+	defaultDashboardInterval = 100 * time.Millisecond
+)
+
+//go:embed dashboard.html
+var dashboardPage []byte
+
+// DashboardOption is a function that can be used to configure the handler created by DashboardHandler().
+type DashboardOption func(*dashboardHandler)
+
+// WithDashboardInterval sets the time between each snapshot sent to the dashboard clients.
+// Default is RefreshInterval (or 100ms if RefreshInterval is 0).
+func WithDashboardInterval(interval time.Duration) DashboardOption {
+	return func(dh *dashboardHandler) {
+		if interval > 0 {
+			dh.interval = interval
+		}
+	}
+}
+
+// DashboardHandler returns an http.Handler serving a single page web dashboard of the live progress on "/" and
+// its Server-Sent Events stream on "/events". Mount it with http.StripPrefix() to serve it under another path.
+// The stream sends a "snapshot" event (a DashboardSnapshot as JSON) at each interval and a "log" event for each line
+// written thru Bypass() (the last DashboardLogHistory lines are sent on connection). The live progress does not need to be
+// started, and nothing is exposed unless the handler is served: bind it to a loopback address if it must stay local.
+func DashboardHandler(opts ...DashboardOption) http.Handler {
+	dh := &dashboardHandler{
+		interval: RefreshInterval,
+	}
+	if dh.interval <= 0 {
+		dh.interval = defaultDashboardInterval
+	}
+	for _, opt := range opts {
+		opt(dh)
+	}
+	dashboardLogs.enable()
+	return dh
+}
+
+// DashboardSnapshot is the state of the live progress sent to the dashboard clients.
+type DashboardSnapshot struct {
+	Time  time.Time       `json:"time"`
+	Items []DashboardItem `json:"items"`
+}
+
+// DashboardItem is a bar or a custom line of a DashboardSnapshot, in display order (main line last).
+type DashboardItem struct {
+	Type string `json:"type"` // "bar" or "line"
+	// custom line
+	Text string `json:"text,omitempty"`
+	// bar
+	ID         uint64   `json:"id,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Group      string   `json:"group,omitempty"`
+	Current    uint64   `json:"current"`
+	Total      uint64   `json:"total"`
+	Ratio      float64  `json:"ratio"`
+	Percent    string   `json:"percent,omitempty"`
+	Rate       float64  `json:"rate"`
+	ETA        string   `json:"eta,omitempty"`
+	ETASeconds *float64 `json:"etaSeconds,omitempty"`
+	Done       bool     `json:"done"`
+	Aborted    bool     `json:"aborted"`
+	Paused     bool     `json:"paused"`
+}
+
+// Snapshot returns the current state of the registered bars and custom lines, as sent by DashboardHandler().
+func Snapshot() (snapshot DashboardSnapshot) {
+	current := registeredItems()
+	defer renderAccess.Unlock()
+	renderAccess.Lock()
+	snapshot.Time = DefaultClock.Now()
+	snapshot.Items = make([]DashboardItem, 0, len(current.items)+1)
+	for _, item := range current.items {
+		snapshot.Items = append(snapshot.Items, snapshotItem(item))
+	}
+	if current.mainItem != nil {
+		snapshot.Items = append(snapshot.Items, snapshotItem(current.mainItem))
+	}
+	return
+}
+
+func snapshotItem(item fmt.Stringer) DashboardItem {
+	bar, ok := item.(*Bar)
+	if !ok {
+		return DashboardItem{
+			Type: "line",
+			Text: stripTermSequences(item.String()),
+		}
+	}
+	progress := bar.Progress()
+	if bar.Total() == 0 {
+		// nothing to progress thru: avoid the NaN (or infinite) ratio which can not be encoded in JSON
+		progress = 0
+	}
+	snapshot := DashboardItem{
+		Type:    "bar",
+		ID:      bar.ID(),
+		Name:    bar.Name(),
+		Group:   bar.Group(),
+		Current: bar.Current(),
+		Total:   bar.Total(),
+		Ratio:   progress,
+		Percent: strings.TrimSpace(getPercent(progress)),
+		Rate:    bar.Rate(),
+		ETA:     getRemainingTime(bar.ActiveDuration(), progress),
+		Done:    bar.Completed(),
+		Aborted: bar.Aborted(),
+		Paused:  bar.Paused(),
+	}
+	if remaining, known := bar.Remaining(); known && bar.Total() > 0 {
+		seconds := remaining.Seconds()
+		snapshot.ETASeconds = &seconds
+	}
+	return snapshot
+}
+
+type dashboardHandler struct {
+	interval time.Duration
+}
+
+func (dh *dashboardHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/", "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(dashboardPage)
+	case "/events":
+		dh.serveEvents(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (dh *dashboardHandler) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	logs, history := dashboardLogs.subscribe()
+	defer dashboardLogs.unsubscribe(logs)
+	for _, line := range history {
+		writeEvent(w, "log", line)
+	}
+	writeEvent(w, "snapshot", Snapshot())
+	flusher.Flush()
+	ticker := DefaultClock.NewTicker(dh.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-logs:
+			writeEvent(w, "log", line)
+		case <-ticker.C():
+			writeEvent(w, "snapshot", Snapshot())
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, data any) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		// let the client know instead of silently skipping the event
+		payload, _ = json.Marshal(fmt.Sprintf("failed to encode %s event: %s", event, err))
+		event = "failure"
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
+/*
+	Bypass lines broadcasting
+*/
+
+var dashboardLogs logHub
+
+// logHub broadcasts the lines written thru Bypass() to the dashboard clients, once a dashboard handler has been created.
+type logHub struct {
+	enabled     bool
+	pending     bytes.Buffer
+	history     []string
+	subscribers map[chan string]struct{}
+	access      sync.Mutex
+}
+
+func (lh *logHub) enable() {
+	defer lh.access.Unlock()
+	lh.access.Lock()
+	if lh.enabled {
+		return
+	}
+	lh.enabled = true
+	lh.subscribers = make(map[chan string]struct{})
+}
+
+func (lh *logHub) subscribe() (lines chan string, history []string) {
+	defer lh.access.Unlock()
+	lh.access.Lock()
+	lines = make(chan string, DashboardLogHistory)
+	lh.subscribers[lines] = struct{}{}
+	return lines, append([]string(nil), lh.history...)
+}
+
+func (lh *logHub) unsubscribe(lines chan string) {
+	defer lh.access.Unlock()
+	lh.access.Lock()
+	delete(lh.subscribers, lines)
+}
+
+// publish splits p in lines and sends them to the subscribers, keeping an incomplete last line until it is completed.
+// Slow subscribers miss lines rather than slowing down Bypass() writers.
+func (lh *logHub) publish(p []byte) {
+	defer lh.access.Unlock()
+	lh.access.Lock()
+	if !lh.enabled {
+		return
+	}
+	lh.pending.Write(p)
+	for {
+		newLine := bytes.IndexByte(lh.pending.Bytes(), '\n')
+		if newLine < 0 {
+			return
+		}
+		line := stripTermSequences(strings.TrimSuffix(string(lh.pending.Next(newLine + 1)[:newLine]), "\r"))
+		if len(lh.history) == DashboardLogHistory {
+			lh.history = append(lh.history[:0], lh.history[1:]...)
+		}
+		lh.history = append(lh.history, line)
+		for subscriber := range lh.subscribers {
+			select {
+			case subscriber <- line:
+			default:
+			}
+		}
+	}
+}
+
+// stripTermSequences removes the terminal sequences (styles, hyperlinks, etc...) from s.
+func stripTermSequences(s string) string {
+	if !strings.ContainsRune(s, ansi.Marker) {
+		return s
+	}
+	var (
+		stripped      strings.Builder
+		withinTermSeq bool
+	)
+	stripped.Grow(len(s))
+	for _, r := range s {
+		if withinTermSeq {
+			if ansi.IsTerminator(r) {
+				withinTermSeq = false
+			}
+			continue
+		}
+		if r == ansi.Marker {
+			withinTermSeq = true
+			continue
+		}
+		stripped.WriteRune(r)
+	}
+	return stripped.String()
+}
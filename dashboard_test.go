@@ -0,0 +1,120 @@
+package liveprogress_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+)
+
+// nextEvent reads the next Server-Sent Event of the stream.
+func nextEvent(t *testing.T, stream *bufio.Reader) (event, data string) {
+	t.Helper()
+	for {
+		line, err := stream.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read event stream: %s", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			return
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestDashboardHandler(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	useOutput(t, io.Discard, nil, nil)
+	server := httptest.NewServer(liveprogress.DashboardHandler(liveprogress.WithDashboardInterval(10 * time.Millisecond)))
+	defer server.Close()
+	// page
+	response, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to get dashboard page: %s", err)
+	}
+	page, _ := io.ReadAll(response.Body)
+	response.Body.Close()
+	if !strings.Contains(string(page), `new EventSource("events")`) {
+		t.Error("dashboard page does not subscribe to the events stream")
+	}
+	// events
+	liveprogress.AddBar(liveprogress.WithName("copy"), liveprogress.WithTotal(10)).CurrentSet(5)
+	liveprogress.AddCustomLine(func() string { return liveprogress.BaseStyle().Bold().Styled("custom") })
+	fmt.Fprintln(liveprogress.Bypass(), "before connection")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	if response, err = http.DefaultClient.Do(request); err != nil {
+		t.Fatalf("failed to get events stream: %s", err)
+	}
+	defer response.Body.Close()
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("unexpected content type: %s", contentType)
+	}
+	stream := bufio.NewReader(response.Body)
+	// logs history then first snapshot
+	var (
+		event, data string
+		history     []string
+	)
+	for event, data = nextEvent(t, stream); event == "log"; event, data = nextEvent(t, stream) {
+		history = append(history, data)
+	}
+	if len(history) == 0 || history[len(history)-1] != `"before connection"` {
+		t.Errorf("expected logs history to end with the line written before connection, got %v", history)
+	}
+	if event != "snapshot" {
+		t.Fatalf("expected snapshot event, got %s: %s", event, data)
+	}
+	var snapshot liveprogress.DashboardSnapshot
+	if err = json.Unmarshal([]byte(data), &snapshot); err != nil {
+		t.Fatalf("failed to decode snapshot: %s", err)
+	}
+	if len(snapshot.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(snapshot.Items))
+	}
+	if bar := snapshot.Items[0]; bar.Type != "bar" || bar.Name != "copy" || bar.Current != 5 || bar.Percent != "50%" {
+		t.Errorf("unexpected bar snapshot: %+v", bar)
+	}
+	if line := snapshot.Items[1]; line.Type != "line" || line.Text != "custom" {
+		t.Errorf("unexpected custom line snapshot: %+v", line)
+	}
+	// live logs
+	fmt.Fprintln(liveprogress.Bypass(), "after connection")
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		if event, data = nextEvent(t, stream); event == "log" {
+			break
+		}
+	}
+	if data != `"after connection"` {
+		t.Errorf("expected live log event, got %s: %s", event, data)
+	}
+}
+
+func TestSnapshotZeroTotal(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	liveprogress.AddBar(liveprogress.WithTotal(0))
+	liveprogress.AddBar(liveprogress.WithTotal(0)).CurrentSet(3)
+	snapshot := liveprogress.Snapshot()
+	if _, err := json.Marshal(snapshot); err != nil {
+		t.Fatalf("snapshot with bars without total should be encodable: %s", err)
+	}
+	for _, bar := range snapshot.Items {
+		if bar.Ratio != 0 || bar.ETASeconds != nil {
+			t.Errorf("unexpected bar without total snapshot: %+v", bar)
+		}
+	}
+}
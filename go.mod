@@ -1,18 +1,18 @@
 module github.com/hekmon/liveprogress/v2
 
-go 1.18
+go 1.21
 
 require (
-	github.com/hekmon/liveterm/v2 v2.5.0
+	github.com/lucasb-eyer/go-colorful v1.2.0
 	github.com/mattn/go-isatty v0.0.20
 	github.com/mattn/go-runewidth v0.0.16
 	github.com/muesli/ansi v0.0.0-20230316100256-276c6243b2f6
 	github.com/muesli/termenv v0.15.2
+	golang.org/x/sys v0.25.0
+	golang.org/x/term v0.24.0
 )
 
 require (
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
-	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.25.0 // indirect
 )
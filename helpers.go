@@ -1,37 +1,154 @@
 package liveprogress
 
 import (
-	"github.com/hekmon/liveterm/v2"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
 	"github.com/muesli/termenv"
 )
 
+var (
+	colorProfile         termenv.Profile
+	colorProfileOverride bool
+	colorProfileAccess   sync.RWMutex
+	colorProfileHooks    []func()
+)
+
 // BaseStyle returns a base termenv style with its terminal profile correctly set.
 // You can use it to create your own styles by modifying the returned style and use it in decorators.
 // You should call this function after Start() if you have changed default Output value.
 func BaseStyle() termenv.Style {
-	return liveterm.GetTermProfile().String()
+	return GetTermProfile().String()
 }
 
-// GetTermProfile returns the termenv profile used by liveprogress (actually by liveterm).
+// GetTermProfile returns the termenv profile used by liveprogress.
 // It can be used to create styles and colors that will be compatible with the terminal. See BaseStyle() for a more high level helper.
+// By order of precedence, the profile is: the one set with SetColorProfile(), the one requested by the NO_COLOR, FORCE_COLOR,
+// CLICOLOR_FORCE and CLICOLOR environment variables or finally the one detected for Output. Writers which are not files
+// are detected as not supporting colors, unless OutputIsTerminal declares them as terminals: the profile is then detected from the environment (TERM, COLORTERM).
 // You should call this function after Start() if you have changed default Output value.
 func GetTermProfile() termenv.Profile {
-	return liveterm.GetTermProfile()
+	colorProfileAccess.RLock()
+	if colorProfileOverride {
+		defer colorProfileAccess.RUnlock()
+		return colorProfile
+	}
+	colorProfileAccess.RUnlock()
+	detected := termOutput().Profile
+	if profile, found := envColorProfile(detected); found {
+		return profile
+	}
+	return detected
+}
+
+// SetColorProfile overrides the termenv profile returned by GetTermProfile() (and so used by BaseStyle()),
+// taking precedence over terminal detection and environment variables.
+// Bars created after this call will use it, and the colors package styles are regenerated (see OnColorProfileChange()).
+func SetColorProfile(profile termenv.Profile) {
+	colorProfileAccess.Lock()
+	colorProfile = profile
+	colorProfileOverride = true
+	colorProfileAccess.Unlock()
+	colorProfileChanged()
+}
+
+// ResetColorProfile removes the override set by SetColorProfile(): profile is detected again.
+func ResetColorProfile() {
+	colorProfileAccess.Lock()
+	colorProfileOverride = false
+	colorProfileAccess.Unlock()
+	colorProfileChanged()
+}
+
+// OnColorProfileChange registers hook to be called after each SetColorProfile() and ResetColorProfile() call,
+// allowing styles created with BaseStyle() to be regenerated. The colors package registers its Generate() function.
+func OnColorProfileChange(hook func()) {
+	if hook == nil {
+		return
+	}
+	defer colorProfileAccess.Unlock()
+	colorProfileAccess.Lock()
+	colorProfileHooks = append(colorProfileHooks, hook)
+}
+
+// colorProfileChanged calls the hooks registered with OnColorProfileChange().
+func colorProfileChanged() {
+	colorProfileAccess.RLock()
+	hooks := colorProfileHooks
+	colorProfileAccess.RUnlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// envColorProfile returns the profile requested by the environment, if any.
+// NO_COLOR (https://no-color.org) takes precedence over FORCE_COLOR (https://force-color.org), which takes precedence
+// over CLICOLOR_FORCE and CLICOLOR (https://bixense.com/clicolors/). Empty values are ignored.
+func envColorProfile(detected termenv.Profile) (profile termenv.Profile, found bool) {
+	if os.Getenv("NO_COLOR") != "" {
+		return termenv.Ascii, true
+	}
+	if forceColor := os.Getenv("FORCE_COLOR"); forceColor != "" {
+		switch strings.ToLower(forceColor) {
+		case "0", "false":
+			return termenv.Ascii, true
+		case "2":
+			return bestProfile(detected, termenv.ANSI256), true
+		case "3":
+			return bestProfile(detected, termenv.TrueColor), true
+		default:
+			return bestProfile(detected, forcedProfile()), true
+		}
+	}
+	if cliColorForce := os.Getenv("CLICOLOR_FORCE"); cliColorForce != "" && cliColorForce != "0" {
+		return bestProfile(detected, forcedProfile()), true
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return termenv.Ascii, true
+	}
+	return
+}
+
+// forcedProfile guesses the profile to use when colors are forced on an output that may not be a terminal.
+func forcedProfile() termenv.Profile {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return termenv.TrueColor
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return termenv.ANSI256
+	}
+	return termenv.ANSI
+}
+
+// bestProfile returns the profile supporting the most colors (termenv profiles are ordered from TrueColor to Ascii).
+func bestProfile(a, b termenv.Profile) termenv.Profile {
+	if a < b {
+		return a
+	}
+	return b
 }
 
 // HasDarkBackground returns whether terminal uses a dark-ish background.
 // You should call this function after Start() if you have changed default Output value.
 func HasDarkBackground() bool {
-	return liveterm.HasDarkBackground()
+	return termOutput().HasDarkBackground()
 }
 
 // Hyperlink creates a hyperlink that can be printed to the terminal.
 func Hyperlink(link string, name string) string {
-	return liveterm.Hyperlink(link, name)
+	return termOutput().Hyperlink(link, name)
 }
 
 // Notify triggers a notification.
 // You should call this function after Start() if you have changed default Output value.
 func Notify(title, body string) {
-	liveterm.Notify(title, body)
+	if running.Load() {
+		// Do not write directly on the terminal as the next refresh would erase it, write it definitively thru Bypass()
+		fmt.Fprintf(Bypass(), "%s777;notify;%s;%s%s", termenv.OSC, title, body, termenv.ST)
+		return
+	}
+	termOutput().Notify(title, body)
 }
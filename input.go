@@ -0,0 +1,179 @@
+package liveprogress
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+const (
+	KeyCtrlC = '\x03' // KeyCtrlC is the key read when Ctrl-C is pressed while the input is handled, see HandleInput().
+)
+
+var (
+	// ErrInputCanceled is the cause of the context returned by HandleInput() when it is canceled from the keyboard.
+	ErrInputCanceled = errors.New("canceled from the keyboard")
+)
+
+var (
+	inputConfig    *inputHandler
+	inputStop      chan struct{}
+	inputDone      chan struct{}
+	inputRestore   func()
+	inputAccess    sync.Mutex
+	allPaused      atomic.Bool
+	pauseAllAccess sync.Mutex // keeps the bars registration consistent with PauseAll() and ResumeAll()
+	detailsShown   atomic.Bool
+)
+
+// InputOption is a function that can be used to configure the input handler, see HandleInput().
+type InputOption func(*inputHandler)
+
+// WithKeyBinding binds key to action, replacing the default binding of key if any. action is called from the input
+// handler goroutine: it must not block. See KeyCtrlC for the Ctrl-C key.
+func WithKeyBinding(key rune, action func()) InputOption {
+	return func(ih *inputHandler) {
+		ih.bindings[key] = action
+	}
+}
+
+type inputHandler struct {
+	bindings map[rune]func()
+}
+
+// HandleInput enables the keyboard controls for the next Start() calls: while the live area is shown, standard input
+// is put in raw mode (keys are read without waiting for Enter and are not echoed) and the keys are mapped to actions:
+//   - 'p' pauses or resumes all the bars, see PauseAll() and ResumeAll()
+//   - 'q' or Ctrl-C cancels the returned context (with ErrInputCanceled as cause)
+//   - 'd' shows or hides the detailed decorators, see DetailDecorator()
+//
+// See WithKeyBinding() to add or replace bindings and DisableHandleInput() to disable the controls. Stop() restores the
+// terminal mode. Input is not handled if standard input is not a terminal or if the live progress is disabled. As the
+// terminal does not send the interrupt signal anymore while in raw mode, make sure to watch the returned context.
+func HandleInput(parent context.Context, opts ...InputOption) context.Context {
+	ctx, cancel := context.WithCancelCause(parent)
+	ih := &inputHandler{
+		bindings: map[rune]func(){
+			'p': func() {
+				if AllPaused() {
+					ResumeAll()
+				} else {
+					PauseAll()
+				}
+			},
+			'q':      func() { cancel(ErrInputCanceled) },
+			KeyCtrlC: func() { cancel(ErrInputCanceled) },
+			'd':      func() { ShowDetails(!DetailsShown()) },
+		},
+	}
+	for _, opt := range opts {
+		opt(ih)
+	}
+	inputAccess.Lock()
+	inputConfig = ih
+	inputAccess.Unlock()
+	return ctx
+}
+
+// DisableHandleInput disables the keyboard controls enabled by HandleInput() for the next Start() calls.
+// If liveprogress is running, the keys are still handled until Stop().
+func DisableHandleInput() {
+	inputAccess.Lock()
+	inputConfig = nil
+	inputAccess.Unlock()
+}
+
+// PauseAll pauses all the registered bars (see Bar.Pause()) and marks them as paused, see AllPaused().
+// Bars added while paused start paused. It is bound to the 'p' key by HandleInput().
+func PauseAll() {
+	defer pauseAllAccess.Unlock()
+	pauseAllAccess.Lock()
+	allPaused.Store(true)
+	for _, bar := range registeredBars() {
+		bar.setPaused(pausedByAll, true)
+	}
+}
+
+// ResumeAll resumes the bars paused by PauseAll(). Bars also paused by Bar.Pause() stay paused.
+func ResumeAll() {
+	defer pauseAllAccess.Unlock()
+	pauseAllAccess.Lock()
+	allPaused.Store(false)
+	for _, bar := range registeredBars() {
+		bar.setPaused(pausedByAll, false)
+	}
+}
+
+// AllPaused returns true if the bars have been paused by PauseAll() (or the 'p' key, see HandleInput()).
+// Check it from your workers to suspend them while the user has paused the progress.
+func AllPaused() bool {
+	return allPaused.Load()
+}
+
+// ShowDetails shows or hides the detailed decorators, see DetailDecorator(). It is bound to the 'd' key by HandleInput().
+func ShowDetails(show bool) {
+	detailsShown.Store(show)
+	requestRefresh()
+}
+
+// DetailsShown returns true if the detailed decorators are shown, see ShowDetails(). They are hidden by default.
+func DetailsShown() bool {
+	return detailsShown.Load()
+}
+
+// DetailDecorator returns a decorator rendering decorator only while the detailed decorators are shown, see ShowDetails().
+// Use it with WithAppendDecorator() or WithPrependDecorator().
+func DetailDecorator(decorator DecoratorFunc) DecoratorFunc {
+	return func(pb *Bar) string {
+		if !DetailsShown() {
+			return ""
+		}
+		return decorator(pb)
+	}
+}
+
+// startInput puts standard input in raw mode and starts reading keys, if HandleInput() has been called.
+func startInput() (err error) {
+	defer inputAccess.Unlock()
+	inputAccess.Lock()
+	if inputConfig == nil || inputStop != nil || !isTerminalFile(os.Stdin) {
+		return
+	}
+	fd := int(os.Stdin.Fd())
+	if inputRestore, err = makeInputRaw(fd); err != nil {
+		return
+	}
+	inputStop = make(chan struct{})
+	inputDone = make(chan struct{})
+	go func(ih *inputHandler, stop, done chan struct{}) {
+		defer close(done)
+		readInput(fd, stop, ih.handleKeys)
+	}(inputConfig, inputStop, inputDone)
+	return
+}
+
+// stopInput stops reading keys and restores standard input mode.
+func stopInput() {
+	defer inputAccess.Unlock()
+	inputAccess.Lock()
+	if inputStop == nil {
+		return
+	}
+	close(inputStop)
+	<-inputDone
+	inputRestore()
+	inputStop, inputDone, inputRestore = nil, nil, nil
+}
+
+func (ih *inputHandler) handleKeys(p []byte) {
+	for len(p) > 0 {
+		key, size := utf8.DecodeRune(p)
+		p = p[size:]
+		if action, found := ih.bindings[key]; found && action != nil {
+			action()
+		}
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos)
+
+package liveprogress
+
+import (
+	"os"
+
+	"golang.org/x/term"
+)
+
+func makeInputRaw(fd int) (restore func(), err error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return
+	}
+	return func() {
+		_ = term.Restore(fd, state)
+	}, nil
+}
+
+// readInput reads standard input until stop is closed, calling handle for each read.
+// Reads can not be interrupted on these systems: the pending one is abandoned when stop is closed.
+func readInput(_ int, stop <-chan struct{}, handle func(p []byte)) {
+	reads := make(chan []byte)
+	go func() {
+		buf := make([]byte, 64)
+		for {
+			n, err := os.Stdin.Read(buf)
+			if n > 0 {
+				select {
+				case reads <- append([]byte(nil), buf[:n]...):
+				case <-stop:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case <-stop:
+			return
+		case p := <-reads:
+			handle(p)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+package liveprogress
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// makeInputRaw disables the line buffering, echo and signals of the terminal fd. Unlike term.MakeRaw(),
+// output processing is kept as the live progress shares the terminal. Reads are made to time out after 100ms.
+func makeInputRaw(fd int) (restore func(), err error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlReadTermios)
+	if err != nil {
+		return
+	}
+	original := *termios
+	termios.Lflag &^= unix.ECHO | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Iflag &^= unix.IXON | unix.ICRNL
+	termios.Cc[unix.VMIN] = 0
+	termios.Cc[unix.VTIME] = 1 // in tenths of second
+	if err = unix.IoctlSetTermios(fd, ioctlWriteTermios, termios); err != nil {
+		return
+	}
+	return func() {
+		_ = unix.IoctlSetTermios(fd, ioctlWriteTermios, &original)
+	}, nil
+}
+
+// readInput reads fd until stop is closed or the input ends (hang up or end of file), calling handle for each read.
+// fd must have been set up by makeInputRaw().
+func readInput(fd int, stop <-chan struct{}, handle func(p []byte)) {
+	buf := make([]byte, 64)
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		// wait for data (at most 100ms to check stop): reads returning nothing right away would spin after a hang up
+		ready, err := unix.Poll(fds, 100)
+		if err != nil {
+			if err == unix.EINTR {
+				continue
+			}
+			return
+		}
+		if ready == 0 {
+			continue
+		}
+		if fds[0].Revents&unix.POLLIN == 0 {
+			// hang up or error without pending data
+			return
+		}
+		n, err := unix.Read(fd, buf)
+		if n > 0 {
+			handle(buf[:n])
+			continue
+		}
+		if err == nil {
+			// readable without data: end of input
+			return
+		}
+		if err != unix.EINTR && err != unix.EAGAIN {
+			return
+		}
+	}
+}
@@ -5,17 +5,26 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
-
-	"github.com/hekmon/liveterm/v2"
-	"github.com/mattn/go-isatty"
 )
 
 var (
 	// Config values (used by Start())
-	RefreshInterval = 100 * time.Millisecond // RefreshInterval is the time between each refresh of the terminal. Recommended value, setting it lower might flicker the terminal and increase CPU usage.
-	Output          = os.Stdout              // Output is the writer the live progress will write to.
+	RefreshInterval              = 100 * time.Millisecond // RefreshInterval is the minimum time between two refreshes of the terminal: bars updates are coalesced and frames without changes are skipped. Recommended value, setting it lower increases CPU usage. Set it to 0 to only refresh when Tick() is called.
+	RefreshMaxInterval           = time.Second            // RefreshMaxInterval is the maximum time between two refreshes of the terminal, even without changes: time based decorators and custom lines are kept up to date at this rate (see Refresh() to refresh them sooner).
+	Output             io.Writer = os.Stdout              // Output is the writer the live progress will write to. Terminal capabilities are detected automatically for *os.File, see OutputIsTerminal, OutputSize and SetColorProfile() for other writers.
+	DefaultClock                 = SystemClock()          // DefaultClock is the clock used by the refresh loop and by bars and spinners created without their own clock.
+	// OutputIsTerminal overrides the detection of Output being a terminal (the live progress is disabled otherwise), for example
+	// for an SSH session channel or a pty master. Leave it nil to detect it automatically: only terminal *os.File are terminals.
+	// It also allows color profile detection from the environment for writers which are not files, see GetTermProfile().
+	OutputIsTerminal func() bool
+	// OutputSize overrides the detection of Output size in columns and rows. It is called at each refresh to handle resizes.
+	// Leave it nil to detect it automatically for *os.File outputs. A size of 0 is unknown: lines wrapping is then not accounted for.
+	OutputSize func() (cols, rows int)
 	// BarAutoSizeSameSize sets progress bars with automatic width (width of 0) to automatically adjust theirs width (and center themself) to all others automatic width bars.
 	// By default left and right decorators will have external padding to center all the automatic length bars, eaning that white spaces will be added to the left for left
 	// decorators group and to the right for right decorators group. See WithInternalPadding() at bar creation to change the padding position.
@@ -23,31 +32,80 @@ var (
 )
 
 var (
-	disabled    bool
-	items       []fmt.Stringer
-	mainItem    fmt.Stringer
-	output      bytes.Buffer
-	itemsAccess sync.Mutex
+	disabled      bool
+	running       atomic.Bool
+	refresherStop chan struct{}
+	refresherDone chan struct{}
+	refreshNeeded atomic.Bool
+	redrawNeeded  = make(chan struct{}, 1)
+	registry      atomic.Pointer[itemsRegistry] // current registered items, rendered without locking
+	itemsAccess   sync.Mutex                    // serializes the registry updates
+	renderAccess  sync.Mutex                    // serializes the rendering: decorators and custom lines generators are never called concurrently
 )
 
+// itemsRegistry is an immutable state of the registered items: each change stores a new one (see updateItems()),
+// allowing the renderer to run the user decorators and custom lines generators without blocking the changes.
+type itemsRegistry struct {
+	items    []fmt.Stringer
+	mainItem fmt.Stringer
+}
+
+// registeredItems returns the current registry. It must not be modified.
+func registeredItems() *itemsRegistry {
+	if current := registry.Load(); current != nil {
+		return current
+	}
+	return &itemsRegistry{}
+}
+
+// updateItems stores the registry returned by update, called with a copy of the current one.
+// Structural changes are drawn immediately.
+func updateItems(update func(next *itemsRegistry)) {
+	itemsAccess.Lock()
+	current := registeredItems()
+	next := &itemsRegistry{
+		items:    slices.Clone(current.items),
+		mainItem: current.mainItem,
+	}
+	update(next)
+	registry.Store(next)
+	itemsAccess.Unlock()
+	requestRedraw()
+}
+
+// NewBar creates a new progress bar without adding it to the live progress, see RenderItems().
+func NewBar(opts ...BarOption) *Bar {
+	return newBar(opts...)
+}
+
 // AddBar adds a new progress bar to the live progress. Only call it after Start() has been called.
 func AddBar(opts ...BarOption) (pb *Bar) {
 	if pb = newBar(opts...); pb == nil {
 		return
 	}
 	// Register the bar
-	itemsAccess.Lock()
-	items = append(items, pb)
-	itemsAccess.Unlock()
+	registerBar(pb, func(next *itemsRegistry) {
+		next.items = append(next.items, pb)
+	})
 	return
 }
 
+// registerBar registers pb with register (see updateItems()), paused if all the bars are paused (see PauseAll()).
+func registerBar(pb *Bar, register func(next *itemsRegistry)) {
+	defer pauseAllAccess.Unlock()
+	pauseAllAccess.Lock()
+	if allPaused.Load() {
+		pb.setPaused(pausedByAll, true)
+	}
+	updateItems(register)
+}
+
 // RemoveAll removes all bars and custom lines from the live progress but does not stop the liveprogress itself.
 func RemoveAll() {
-	itemsAccess.Lock()
-	items = make([]fmt.Stringer, 0, 1)
-	mainItem = nil
-	itemsAccess.Unlock()
+	updateItems(func(next *itemsRegistry) {
+		next.items = nil
+		next.mainItem = nil
+	})
 }
 
 // RemoveBar removes a bar from the live progress.
@@ -56,20 +114,37 @@ func RemoveBar(pb *Bar) {
 	if pb == nil {
 		return
 	}
-	defer itemsAccess.Unlock()
-	itemsAccess.Lock()
-	// Is it the main item?
-	if mainItemBar, ok := mainItem.(*Bar); ok && mainItemBar == pb {
-		mainItem = nil
-		return
-	}
-	// Search for the bar
-	for index, item := range items {
-		if item, ok := item.(*Bar); ok && item == pb {
-			items = append(items[:index], items[index+1:]...)
-			break
+	removeItem(pb)
+}
+
+// removeItem removes item from the registry, be it the main item or not.
+func removeItem(item fmt.Stringer) {
+	updateItems(func(next *itemsRegistry) {
+		// Is it the main item?
+		if next.mainItem == item {
+			next.mainItem = nil
+			return
+		}
+		// Search for the item
+		if index := slices.Index(next.items, item); index >= 0 {
+			next.items = slices.Delete(next.items, index, index+1)
+		}
+	})
+}
+
+// registeredBars returns the bars registered in the live progress, main line last.
+func registeredBars() (bars []*Bar) {
+	current := registeredItems()
+	bars = make([]*Bar, 0, len(current.items)+1)
+	for _, item := range current.items {
+		if bar, ok := item.(*Bar); ok {
+			bars = append(bars, bar)
 		}
 	}
+	if mainBar, ok := current.mainItem.(*Bar); ok {
+		bars = append(bars, mainBar)
+	}
+	return
 }
 
 // SetMainLineAsBar sets the main line as a bar. MainLine will always be the last line.
@@ -79,49 +154,157 @@ func SetMainLineAsBar(opts ...BarOption) (pb *Bar) {
 		return
 	}
 	// Register the bar
-	itemsAccess.Lock()
-	mainItem = pb
-	itemsAccess.Unlock()
+	registerBar(pb, func(next *itemsRegistry) {
+		next.mainItem = pb
+	})
 	return
 }
 
 // Start starts the live progress. It will render every bars and custom lines added after.
 // It is important to note that Output (default to os.Stdout) should not be used directly (for example with fmt.Print*()) after Start() is called and until Stop() is called.
-// See ByPass() to get a writer that will bypass the live progress and write definitive lines directly to the output without disrupting live progress.
+// See ByPass() to get a writer that will bypass the live progress and write definitive lines directly to the output without disrupting live progress,
+// or CaptureStdio() to automatically redirect the standard outputs thru it.
 func Start() (err error) {
-	if !isatty.IsTerminal(Output.Fd()) {
-		disabled = true
+	if disabled = !outputIsTerminal(Output); disabled {
 		fmt.Fprintln(Output, "Live progress disabled because Output is not a terminal. Bypass writes will still be printed.")
 		return
 	}
-	liveterm.RefreshInterval = RefreshInterval
-	liveterm.Output = Output
-	liveterm.SetRawUpdateFx(updater)
-	liveterm.HideCursor = true
-	return liveterm.Start()
+	// Redirect standard outputs if requested (see CaptureStdio())
+	target, err := startCapture(Output)
+	if err != nil {
+		return fmt.Errorf("failed to capture standard outputs: %w", err)
+	}
+	if err = startScreen(target); err != nil {
+		stopCapture()()
+		return
+	}
+	// Read keys if requested (see HandleInput())
+	if err = startInput(); err != nil {
+		_ = stopScreen(true)
+		stopCapture()()
+		return fmt.Errorf("failed to handle input: %w", err)
+	}
+	running.Store(true)
+	if RefreshInterval > 0 {
+		refresherStop = make(chan struct{})
+		refresherDone = make(chan struct{})
+		go refresher(DefaultClock, refresherStop, refresherDone)
+	}
+	return
+}
+
+// Tick refreshes the terminal immediately. Use it to drive the refreshes from your own event loop
+// (setting RefreshInterval to 0 before calling Start() disables the automatic refreshes).
+// It does nothing if liveprogress is not started or disabled.
+func Tick() {
+	if disabled {
+		return
+	}
+	updateScreen()
+}
+
+// RenderItems renders items (bars, custom lines or any fmt.Stringer) the same way Render() does for the registered ones,
+// including BarsAutoSizeSameSize alignment. Use it with items created by NewBar() and NewCustomLine() to embed them
+// within another user interface without registering them in the live progress.
+func RenderItems(width int, items ...fmt.Stringer) (lines []string) {
+	var frame bytes.Buffer
+	renderItems(&frame, items, nil, width)
+	if frame.Len() == 0 {
+		return
+	}
+	return strings.Split(frame.String(), "\n")
+}
+
+// Render returns the current frame (every bars and custom lines, main line last) rendered for a terminal of width columns.
+// It does not need Start() to be called nor a terminal: use it to embed liveprogress items within another
+// user interface or to print them in logs. Styles still follow GetTermProfile(), see SetColorProfile() to change it.
+func Render(width int) (lines []string) {
+	var frame bytes.Buffer
+	renderFrame(&frame, width)
+	if frame.Len() == 0 {
+		return
+	}
+	return strings.Split(frame.String(), "\n")
+}
+
+// Refresh requests a refresh of the terminal at the next RefreshInterval tick. Bars request it themselves when they
+// are updated: use it when a custom line content changes, to show it before the next RefreshMaxInterval refresh.
+func Refresh() {
+	requestRefresh()
+}
+
+// requestRefresh marks the frame as changed: it will be drawn at the next refresher tick.
+func requestRefresh() {
+	if !refreshNeeded.Load() {
+		refreshNeeded.Store(true)
+	}
+}
+
+// requestRedraw wakes the refresher to draw the frame immediately, for structural changes (items added or removed).
+func requestRedraw() {
+	select {
+	case redrawNeeded <- struct{}{}:
+	default:
+	}
+}
+
+// refresher draws the frames: at most once per RefreshInterval when they have changed (see requestRefresh()),
+// immediately on structural changes (see requestRedraw()) and at least once per RefreshMaxInterval.
+func refresher(clock Clock, stop, done chan struct{}) {
+	defer close(done)
+	ticker := clock.NewTicker(RefreshInterval)
+	defer ticker.Stop()
+	lastRefresh := clock.Now()
+	for {
+		select {
+		case <-ticker.C():
+			if !refreshNeeded.Load() && clock.Since(lastRefresh) < RefreshMaxInterval && !screenResized() {
+				// idle frame
+				continue
+			}
+		case <-redrawNeeded:
+		case <-stop:
+			return
+		}
+		refreshNeeded.Store(false)
+		updateScreen()
+		lastRefresh = clock.Now()
+	}
 }
 
 // Stop stops the live progress and remove all registered bars and custom lines from its internal state.
 // Set clear to true to clear the liveprogress output. After this call, Output can be used directly again (no need to use ByPass() anymore).
 func Stop(clear bool) (err error) {
 	if !disabled {
-		// if clear is false, liveterm will call updater one last time
-		err = liveterm.Stop(clear)
-		// Add a newline to separate the live progress output if needed
-		if !clear {
-			if output.Len() > 0 && output.Bytes()[output.Len()-1] != '\n' {
-				fmt.Fprint(Output, "\n")
-			}
+		// stop our refresher before the screen
+		if refresherStop != nil {
+			close(refresherStop)
+			<-refresherDone
+			refresherStop, refresherDone = nil, nil
 		}
+		// restore standard input mode
+		stopInput()
+		// restore standard outputs while the screen can still print their last captured lines
+		releaseCapture := stopCapture()
+		// if clear is false, the last frame is drawn one last time
+		err = stopScreen(clear)
+		running.Store(false)
+		releaseCapture()
 	}
 	RemoveAll()
 	return
 }
 
-func updater() []byte {
-	output.Reset()
-	defer itemsAccess.Unlock()
-	itemsAccess.Lock()
+// renderFrame renders every registered items for a terminal of lineWidth columns into output.
+func renderFrame(output *bytes.Buffer, lineWidth int) {
+	current := registeredItems()
+	renderItems(output, current.items, current.mainItem, lineWidth)
+}
+
+// renderItems renders items then mainItem (if not nil) for a terminal of lineWidth columns into output.
+func renderItems(output *bytes.Buffer, items []fmt.Stringer, mainItem fmt.Stringer, lineWidth int) {
+	defer renderAccess.Unlock()
+	renderAccess.Lock()
 	// Choose mode
 	var autoSizeSameSize int
 	if BarsAutoSizeSameSize {
@@ -141,7 +324,7 @@ func updater() []byte {
 	// Regular 1 pass mode
 	if autoSizeSameSize < 2 {
 		for index, item := range items {
-			output.WriteString(item.String())
+			output.WriteString(renderItem(item, lineWidth))
 			if index < len(items)-1 {
 				output.WriteRune('\n')
 			}
@@ -150,9 +333,9 @@ func updater() []byte {
 			if len(items) > 0 {
 				output.WriteRune('\n')
 			}
-			output.WriteString(mainItem.String())
+			output.WriteString(renderItem(mainItem, lineWidth))
 		}
-		return output.Bytes()
+		return
 	}
 	// 2 pass mode for bar autosize
 	//// 1st pass to get decorators rendering and width
@@ -189,7 +372,6 @@ func updater() []byte {
 		}
 	}
 	// 2nd pass as fixed bar size
-	lineWidth, _ := liveterm.GetTermSize()
 	for index, item := range items {
 		if bar, ok := item.(*Bar); ok {
 			if bar.barWidth == 0 {
@@ -198,7 +380,7 @@ func updater() []byte {
 				output.WriteString(bar.renderAutoSize(pfx[index], afx[index], lineWidth, pfxWidths[index], pfxPadding, afxWidths[index], afxPadding))
 			} else {
 				// progress bar but with fixed size
-				output.WriteString(item.String())
+				output.WriteString(bar.render(lineWidth))
 			}
 		} else {
 			// custom line
@@ -217,10 +399,16 @@ func updater() []byte {
 			afxPadding := biggestAfx - afxWidths[len(afxWidths)-1]
 			output.WriteString(mainBar.renderAutoSize(pfx[len(pfx)-1], afx[len(afx)-1], lineWidth, pfxWidths[len(pfxWidths)-1], pfxPadding, afxWidths[len(afxWidths)-1], afxPadding))
 		} else {
-			output.WriteString(mainItem.String())
+			output.WriteString(renderItem(mainItem, lineWidth))
 		}
 	}
-	return output.Bytes()
+}
+
+func renderItem(item fmt.Stringer, lineWidth int) string {
+	if bar, ok := item.(*Bar); ok {
+		return bar.render(lineWidth)
+	}
+	return item.String()
 }
 
 /*
@@ -229,11 +417,11 @@ func updater() []byte {
 
 // Bypass returns a writer that will bypass the live progress and write directly to the output without being wiped by the next refresh.
 func Bypass() io.Writer {
-	return liveterm.Bypass()
+	return bypassWriter{}
 }
 
 // CustomLine is a custom line to add to the live progress.
-// Do not instantiate it directly, use AddCustomLine() instead.
+// Do not instantiate it directly, use AddCustomLine() or NewCustomLine() instead.
 type CustomLine struct {
 	generator func() string
 }
@@ -243,17 +431,31 @@ func (cl *CustomLine) String() string {
 	return cl.generator()
 }
 
+// NewCustomLine creates a new custom line without adding it to the live progress, see RenderItems().
+func NewCustomLine(generator func() string) (cl *CustomLine) {
+	if generator == nil {
+		return
+	}
+	return &CustomLine{
+		generator: generator,
+	}
+}
+
 // AddCustomLine adds a custom line to the live progress. Only call it after Start() has been called.
+// Frames without changes are skipped (see RefreshInterval): unless its content only depends on bars or spinners (which
+// request refreshes themselves), call Refresh() when the generator output changes or it will only be redrawn every RefreshMaxInterval.
+// The generator is called by the refreshes, Render() and Snapshot() one call at a time, but concurrently with your other goroutines:
+// the state it reads must be safe for concurrent use.
 func AddCustomLine(generator func() string) (cl *CustomLine) {
 	if generator == nil {
 		return
 	}
-	itemsAccess.Lock()
 	cl = &CustomLine{
 		generator: generator,
 	}
-	items = append(items, cl)
-	itemsAccess.Unlock()
+	updateItems(func(next *itemsRegistry) {
+		next.items = append(next.items, cl)
+	})
 	return
 }
 
@@ -262,33 +464,20 @@ func RemoveCustomLine(cl *CustomLine) {
 	if cl == nil {
 		return
 	}
-	defer itemsAccess.Unlock()
-	itemsAccess.Lock()
-	// Is it main item?
-	if mainItemCustomLine, ok := mainItem.(*CustomLine); ok && mainItemCustomLine == cl {
-		mainItem = nil
-		return
-	}
-	// Search in other lines
-	for index, item := range items {
-		if item, ok := item.(*CustomLine); ok && item == cl {
-			items = append(items[:index], items[index+1:]...)
-			break
-		}
-	}
+	removeItem(cl)
 }
 
 // SetMainLineAsCustomLine sets the main line as a custom line. MainLine will always be the last line.
-// Only call it after Start() has been called.
+// Only call it after Start() has been called. See AddCustomLine() about calling Refresh() when its content changes.
 func SetMainLineAsCustomLine(generator func() string) (cl *CustomLine) {
 	if generator == nil {
 		return
 	}
-	itemsAccess.Lock()
 	cl = &CustomLine{
 		generator: generator,
 	}
-	mainItem = cl
-	itemsAccess.Unlock()
+	updateItems(func(next *itemsRegistry) {
+		next.mainItem = cl
+	})
 	return
 }
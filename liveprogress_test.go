@@ -0,0 +1,233 @@
+package liveprogress_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/colors"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+	"github.com/muesli/termenv"
+)
+
+// useOutput sets liveprogress output config values for the duration of the test.
+func useOutput(t *testing.T, output io.Writer, isTerminal func() bool, size func() (cols, rows int)) {
+	t.Cleanup(func() {
+		liveprogress.Output = os.Stdout
+		liveprogress.OutputIsTerminal = nil
+		liveprogress.OutputSize = nil
+		liveprogress.RefreshInterval = 100 * time.Millisecond
+		liveprogress.ResetColorProfile()
+	})
+	liveprogress.Output = output
+	liveprogress.OutputIsTerminal = isTerminal
+	liveprogress.OutputSize = size
+	liveprogress.RefreshInterval = 0
+	liveprogress.SetColorProfile(termenv.Ascii)
+}
+
+func TestOutputWriter(t *testing.T) {
+	terminal := liveprogresstest.NewTerminal(30, 5)
+	useOutput(t, terminal, func() bool { return true }, terminal.Size)
+	if err := liveprogress.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	bar := liveprogress.AddBar(liveprogress.WithWidth(10), liveprogress.WithAppendPercent(liveprogress.BaseStyle()))
+	bar.CurrentSet(50)
+	liveprogress.Tick()
+	if terminal.CursorVisible() {
+		t.Error("cursor should be hidden while running")
+	}
+	fmt.Fprintln(liveprogress.Bypass(), "log line")
+	bar.CurrentSet(100)
+	if err := liveprogress.Stop(false); err != nil {
+		t.Fatalf("failed to stop: %s", err)
+	}
+	if !terminal.CursorVisible() {
+		t.Error("cursor should be visible after stop")
+	}
+	liveprogresstest.AssertGolden(t, "output_writer", terminal.String())
+}
+
+func TestOutputNotTerminal(t *testing.T) {
+	var buffer bytes.Buffer
+	useOutput(t, &buffer, nil, nil)
+	if err := liveprogress.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	liveprogress.AddBar().CurrentSet(50)
+	liveprogress.Tick()
+	fmt.Fprintln(liveprogress.Bypass(), "log line")
+	if err := liveprogress.Stop(false); err != nil {
+		t.Fatalf("failed to stop: %s", err)
+	}
+	if !strings.HasSuffix(buffer.String(), "disabled because Output is not a terminal. Bypass writes will still be printed.\nlog line\n") {
+		t.Errorf("unexpected output for a writer which is not a terminal: %q", buffer.String())
+	}
+}
+
+// waitScreen waits for the terminal screen to contain expected.
+func waitScreen(t *testing.T, terminal *liveprogresstest.Terminal, expected string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(terminal.String(), expected) {
+		if time.Now().After(deadline) {
+			t.Fatalf("screen does not contain %q:\n%s", expected, terminal.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAdaptiveRefresh(t *testing.T) {
+	terminal := liveprogresstest.NewTerminal(30, 5)
+	useOutput(t, terminal, func() bool { return true }, terminal.Size)
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	liveprogress.DefaultClock = clock
+	liveprogress.RefreshInterval = 100 * time.Millisecond
+	t.Cleanup(func() { liveprogress.DefaultClock = liveprogress.SystemClock() })
+	if err := liveprogress.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	defer liveprogress.Stop(true)
+	// structural changes are drawn immediately
+	bar := liveprogress.AddBar(
+		liveprogress.WithWidth(10),
+		liveprogress.WithAppendPercent(liveprogress.BaseStyle()),
+		liveprogress.WithAppendTimeElapsed(liveprogress.BaseStyle()),
+	)
+	waitScreen(t, terminal, "0% 0s")
+	// updates are drawn at the next tick
+	bar.CurrentSet(50)
+	clock.Advance(100 * time.Millisecond)
+	waitScreen(t, terminal, "50% 0s")
+	// idle frames are skipped...
+	clock.Advance(500 * time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if screen := terminal.String(); !strings.Contains(screen, "50% 0s") {
+		t.Fatalf("idle frame should have been skipped:\n%s", screen)
+	}
+	// ...until RefreshMaxInterval is reached
+	clock.Advance(500 * time.Millisecond)
+	waitScreen(t, terminal, "50% 1s")
+}
+
+func TestRenderDoesNotBlockRegistry(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	rendering, release := make(chan struct{}), make(chan struct{})
+	liveprogress.AddCustomLine(func() string {
+		close(rendering)
+		<-release
+		return "slow"
+	})
+	rendered := make(chan []string)
+	go func() { rendered <- liveprogress.Render(30) }()
+	<-rendering
+	// the registry can be changed while the slow generator runs
+	changed := make(chan struct{})
+	go func() {
+		liveprogress.RemoveBar(liveprogress.AddBar())
+		liveprogress.AddCustomLine(func() string { return "added" })
+		close(changed)
+	}()
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Fatal("registry changes are blocked by the rendering")
+	}
+	close(release)
+	// the rendering works on the items registered when it started
+	if lines := <-rendered; len(lines) != 1 || lines[0] != "slow" {
+		t.Errorf("unexpected rendering: %q", lines)
+	}
+}
+
+func TestColorProfileRegeneratesColors(t *testing.T) {
+	t.Cleanup(liveprogress.ResetColorProfile)
+	liveprogress.SetColorProfile(termenv.ANSI)
+	if styled := colors.ANSIBasicRed.Styled("red"); styled == "red" {
+		t.Error("colors styles should be colored with the ANSI profile")
+	}
+	if styled := colors.DarkTheme.Error.Styled("error"); styled == "error" {
+		t.Error("colors themes should be colored with the ANSI profile")
+	}
+	liveprogress.SetColorProfile(termenv.Ascii)
+	if styled := colors.ANSIBasicRed.Styled("red"); styled != "red" {
+		t.Errorf("colors styles should not be colored with the Ascii profile: %q", styled)
+	}
+}
+
+func TestRenderSerialized(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	var inside, overlaps atomic.Int32
+	liveprogress.AddBar(liveprogress.WithAppendDecorator(func(*liveprogress.Bar) string {
+		if inside.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(time.Millisecond)
+		inside.Add(-1)
+		return ""
+	}))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				liveprogress.Render(40)
+				liveprogress.Snapshot()
+			}
+		}()
+	}
+	wg.Wait()
+	if overlaps.Load() != 0 {
+		t.Errorf("decorators were called concurrently %d times", overlaps.Load())
+	}
+}
+
+func TestColorProfileEnvironment(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		terminal bool
+		env      map[string]string
+		expected termenv.Profile
+	}{
+		{name: "not a terminal", expected: termenv.Ascii},
+		{name: "terminal", terminal: true, expected: termenv.ANSI256},
+		{name: "NO_COLOR", terminal: true, env: map[string]string{"NO_COLOR": "1"}, expected: termenv.Ascii},
+		{name: "NO_COLOR over FORCE_COLOR", env: map[string]string{"NO_COLOR": "1", "FORCE_COLOR": "1"}, expected: termenv.Ascii},
+		{name: "FORCE_COLOR", env: map[string]string{"FORCE_COLOR": "1"}, expected: termenv.ANSI256},
+		{name: "FORCE_COLOR basic terminal", env: map[string]string{"FORCE_COLOR": "1", "TERM": "xterm"}, expected: termenv.ANSI},
+		{name: "FORCE_COLOR true", env: map[string]string{"FORCE_COLOR": "true"}, expected: termenv.ANSI256},
+		{name: "FORCE_COLOR 256 colors", env: map[string]string{"FORCE_COLOR": "2"}, expected: termenv.ANSI256},
+		{name: "FORCE_COLOR true colors", env: map[string]string{"FORCE_COLOR": "3"}, expected: termenv.TrueColor},
+		{name: "FORCE_COLOR disabled", terminal: true, env: map[string]string{"FORCE_COLOR": "0"}, expected: termenv.Ascii},
+		{name: "FORCE_COLOR empty", env: map[string]string{"FORCE_COLOR": ""}, expected: termenv.Ascii},
+		{name: "FORCE_COLOR over CLICOLOR_FORCE", env: map[string]string{"FORCE_COLOR": "false", "CLICOLOR_FORCE": "1"}, expected: termenv.Ascii},
+		{name: "CLICOLOR_FORCE", env: map[string]string{"CLICOLOR_FORCE": "1"}, expected: termenv.ANSI256},
+		{name: "CLICOLOR_FORCE disabled", env: map[string]string{"CLICOLOR_FORCE": "0"}, expected: termenv.Ascii},
+		{name: "CLICOLOR_FORCE over CLICOLOR", env: map[string]string{"CLICOLOR_FORCE": "1", "CLICOLOR": "0"}, expected: termenv.ANSI256},
+		{name: "CLICOLOR disabled", terminal: true, env: map[string]string{"CLICOLOR": "0"}, expected: termenv.Ascii},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"NO_COLOR", "FORCE_COLOR", "CLICOLOR_FORCE", "CLICOLOR", "COLORTERM"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv("TERM", "xterm-256color")
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			useOutput(t, &bytes.Buffer{}, func() bool { return test.terminal }, nil)
+			liveprogress.ResetColorProfile()
+			if profile := liveprogress.GetTermProfile(); profile != test.expected {
+				t.Errorf("expected profile %d, got %d", test.expected, profile)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package liveprogressipc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Client renders bars in the live progress of a server process. Use Dial() or DialEnv() to create it.
+// Its methods are safe for concurrent use. Bar updates do not return errors: see Err() for the first one encountered.
+type Client struct {
+	conn    net.Conn
+	encoder *json.Encoder
+	lastBar uint64
+	err     error
+	access  sync.Mutex
+}
+
+// Dial connects to the server listening on the Unix socket at path.
+func Dial(path string) (c *Client, err error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", path, err)
+	}
+	return &Client{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+	}, nil
+}
+
+// DialEnv connects to the server announced by the EnvSocket environment variable.
+func DialEnv() (c *Client, err error) {
+	path := os.Getenv(EnvSocket)
+	if path == "" {
+		return nil, fmt.Errorf("%s environment variable is not set", EnvSocket)
+	}
+	return Dial(path)
+}
+
+// Close disconnects the client once the server has handled all its messages: its bars are removed from the server live progress.
+func (c *Client) Close() error {
+	if unixConn, ok := c.conn.(*net.UnixConn); ok && unixConn.CloseWrite() == nil {
+		// the server closes the connection once it has read everything
+		_, _ = io.Copy(io.Discard, unixConn)
+	}
+	return c.conn.Close()
+}
+
+// Err returns the first error encountered while sending a message to the server, if any.
+func (c *Client) Err() error {
+	defer c.access.Unlock()
+	c.access.Lock()
+	return c.err
+}
+
+func (c *Client) send(msg Message) (err error) {
+	defer c.access.Unlock()
+	c.access.Lock()
+	if c.err != nil {
+		return c.err
+	}
+	if err = c.encoder.Encode(msg); err != nil {
+		c.err = fmt.Errorf("failed to send %s message: %w", msg.Op, err)
+		return c.err
+	}
+	return
+}
+
+// BarOption is a function that can be used to configure a remote bar at creation, see Client.AddBar().
+type BarOption func(*Message)
+
+// WithName sets the name of the bar (shown by the server default decorators and used as metrics label, see liveprogress.WithName()).
+func WithName(name string) BarOption {
+	return func(msg *Message) {
+		msg.Name = name
+	}
+}
+
+// WithGroup sets the group of the bar, see liveprogress.WithGroup().
+func WithGroup(group string) BarOption {
+	return func(msg *Message) {
+		msg.Group = group
+	}
+}
+
+// WithTotal sets the total value of the bar. Default is liveprogress.DefaultTotal.
+func WithTotal(total uint64) BarOption {
+	return func(msg *Message) {
+		msg.Total = total
+	}
+}
+
+// WithKey sets the key identifying the bar on the server instead of a generated one, allowing others clients
+// to update it when the server shares its bars (see WithSharedBars() and Client.Bar()).
+func WithKey(key string) BarOption {
+	return func(msg *Message) {
+		if key != "" {
+			msg.Bar = key
+		}
+	}
+}
+
+// AddBar adds a new bar to the server live progress. A bar already registered with the same key is replaced.
+func (c *Client) AddBar(opts ...BarOption) (b *Bar) {
+	c.access.Lock()
+	c.lastBar++
+	key := strconv.FormatUint(c.lastBar, 10)
+	c.access.Unlock()
+	msg := Message{
+		Op:  OpAddBar,
+		Bar: key,
+	}
+	for _, opt := range opts {
+		opt(&msg)
+	}
+	_ = c.send(msg)
+	return c.Bar(msg.Bar)
+}
+
+// Bar returns a handle on the bar registered on the server as key, see WithKey().
+// Updates of a key not registered (by this connection or, with shared bars, by any connection) are ignored by the server.
+func (c *Client) Bar(key string) *Bar {
+	return &Bar{
+		client: c,
+		key:    key,
+	}
+}
+
+// Stop requests the server to stop (see WithStopHandler()) and waits for it to close the connection.
+func (c *Client) Stop() (err error) {
+	if err = c.send(Message{Op: OpStop}); err != nil {
+		return
+	}
+	_, err = io.Copy(io.Discard, c.conn)
+	return
+}
+
+// Bypass returns a writer whose lines are written above the server live progress, see liveprogress.Bypass().
+func (c *Client) Bypass() io.Writer {
+	return bypassWriter{client: c}
+}
+
+type bypassWriter struct {
+	client *Client
+}
+
+func (bw bypassWriter) Write(p []byte) (n int, err error) {
+	if err = bw.client.send(Message{Op: OpBypass, Text: string(p)}); err != nil {
+		return
+	}
+	return len(p), nil
+}
+
+// Bar is a bar rendered by the server live progress, see Client.AddBar().
+type Bar struct {
+	client *Client
+	key    string
+}
+
+// CurrentAdd adds value to the current value of the bar.
+func (b *Bar) CurrentAdd(value uint64) {
+	_ = b.client.send(Message{Op: OpCurrentAdd, Bar: b.key, Value: value})
+}
+
+// CurrentIncrement increments the current value of the bar by 1.
+func (b *Bar) CurrentIncrement() {
+	b.CurrentAdd(1)
+}
+
+// CurrentSet sets the current value of the bar.
+func (b *Bar) CurrentSet(value uint64) {
+	_ = b.client.send(Message{Op: OpCurrentSet, Bar: b.key, Value: value})
+}
+
+// Complete sets the current value of the bar to its total.
+func (b *Bar) Complete() {
+	_ = b.client.send(Message{Op: OpComplete, Bar: b.key})
+}
+
+// Abort marks the bar as aborted, see liveprogress.Bar.Abort().
+func (b *Bar) Abort() {
+	_ = b.client.send(Message{Op: OpAbort, Bar: b.key})
+}
+
+// Remove removes the bar from the server live progress.
+func (b *Bar) Remove() {
+	_ = b.client.send(Message{Op: OpRemoveBar, Bar: b.key})
+}
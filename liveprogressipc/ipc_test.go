@@ -0,0 +1,109 @@
+package liveprogressipc_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogressipc"
+	"github.com/muesli/termenv"
+)
+
+// waitFor polls condition until it is true or fails the test after a second.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+// syncBuffer is a strings.Builder safe for concurrent use.
+type syncBuffer struct {
+	builder strings.Builder
+	access  sync.Mutex
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	defer sb.access.Unlock()
+	sb.access.Lock()
+	return sb.builder.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	defer sb.access.Unlock()
+	sb.access.Lock()
+	return sb.builder.String()
+}
+
+func screen() string {
+	return strings.Join(liveprogress.Render(40), "\n")
+}
+
+func TestClientServer(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	liveprogress.SetColorProfile(termenv.Ascii)
+	defer liveprogress.ResetColorProfile()
+	var logs syncBuffer
+	liveprogress.Output = &logs
+	defer func() { liveprogress.Output = os.Stdout }()
+
+	server, err := liveprogressipc.Listen("")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	defer server.Close()
+	if os.Getenv(liveprogressipc.EnvSocket) != server.Path() {
+		t.Errorf("%s environment variable is not set to the server path", liveprogressipc.EnvSocket)
+	}
+	client, err := liveprogressipc.DialEnv()
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+	bar := client.AddBar(liveprogressipc.WithName("worker"), liveprogressipc.WithTotal(4))
+	bar.CurrentAdd(1)
+	bar.CurrentIncrement()
+	fmt.Fprint(client.Bypass(), "half ")
+	fmt.Fprintln(client.Bypass(), "done")
+	waitFor(t, "remote bar progress", func() bool { return strings.Contains(screen(), "worker") && strings.Contains(screen(), "50%") })
+	waitFor(t, "remote bypass line", func() bool { return logs.String() == "half done\n" })
+	bar.Complete()
+	waitFor(t, "remote bar completion", func() bool { return strings.Contains(screen(), "100%") })
+	// disconnection removes the client bars
+	fmt.Fprint(client.Bypass(), "unterminated")
+	if err = client.Close(); err != nil {
+		t.Fatalf("failed to close client: %s", err)
+	}
+	waitFor(t, "remote bar removal", func() bool { return screen() == "" })
+	waitFor(t, "pending bypass line flush", func() bool { return strings.HasSuffix(logs.String(), "unterminated\n") })
+	if err = client.Err(); err != nil {
+		t.Errorf("unexpected client error: %s", err)
+	}
+	bar.CurrentAdd(1)
+	if client.Err() == nil {
+		t.Error("sending on a closed client should fail")
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	server, err := liveprogressipc.Listen("")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	path := server.Path()
+	if err = server.Close(); err != nil {
+		t.Fatalf("failed to close server: %s", err)
+	}
+	if _, err = liveprogressipc.Dial(path); err == nil {
+		t.Error("dial should fail once the server is closed")
+	}
+	if os.Getenv(liveprogressipc.EnvSocket) != "" {
+		t.Errorf("%s environment variable should be unset once the server is closed", liveprogressipc.EnvSocket)
+	}
+}
@@ -0,0 +1,36 @@
+// Package liveprogressipc allows other processes (typically forked workers) to render their bars in the live progress
+// of a parent process. The parent listens on a Unix socket (see Listen()) announced to its children thru the
+// LIVEPROGRESS_SOCKET environment variable, and the children connect to it with a Client (see DialEnv()).
+//
+// The protocol is a stream of JSON messages (see Message), one per line, sent by the client. The server never answers:
+// once the connection is closed, the bars created thru it are removed from the live progress (unless the server
+// shares its bars between connections, see WithSharedBars()).
+package liveprogressipc
+
+const (
+	// EnvSocket is the environment variable announcing the socket path of the server to the child processes.
+	EnvSocket = "LIVEPROGRESS_SOCKET"
+)
+
+// Operations of the protocol messages.
+const (
+	OpAddBar     = "add_bar"     // creates the bar Bar with Name, Group and Total
+	OpCurrentAdd = "current_add" // adds Value to the current value of Bar
+	OpCurrentSet = "current_set" // sets the current value of Bar to Value
+	OpComplete   = "complete"    // completes Bar
+	OpAbort      = "abort"       // aborts Bar
+	OpRemoveBar  = "remove_bar"  // removes Bar from the live progress
+	OpBypass     = "bypass"      // writes Text above the live progress, line by line
+	OpStop       = "stop"        // requests the server to stop, see WithStopHandler()
+)
+
+// Message is a protocol message. Bar is the key of the bar the operation applies to, chosen by the client at creation.
+type Message struct {
+	Op    string `json:"op"`
+	Bar   string `json:"bar,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Group string `json:"group,omitempty"`
+	Total uint64 `json:"total,omitempty"`
+	Value uint64 `json:"value,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
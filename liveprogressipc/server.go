@@ -0,0 +1,304 @@
+package liveprogressipc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/hekmon/liveprogress/v2"
+)
+
+const (
+	maxMessageSize = 1024 * 1024
+)
+
+// BarSpec describes a bar requested by a client.
+type BarSpec struct {
+	Name  string
+	Group string
+	Total uint64
+}
+
+// ServerOption is a function that can be used to configure a server at creation, see Listen().
+type ServerOption func(*Server)
+
+// WithBarOptions sets the function returning the liveprogress options (decorators, styles, etc...) of the bars requested
+// by the clients. The name, group and total of the spec are applied by the server, do not set them again.
+// By default bars are prefixed by their name (if any) and suffixed by their percentage.
+func WithBarOptions(barOptions func(spec BarSpec) []liveprogress.BarOption) ServerOption {
+	return func(s *Server) {
+		if barOptions != nil {
+			s.barOptions = barOptions
+		}
+	}
+}
+
+// WithSharedBars makes the bars independent of the connection which created them: their keys are shared by all the
+// connections and they are not removed on disconnection. Use it for a daemon driven by short lived clients.
+func WithSharedBars() ServerOption {
+	return func(s *Server) {
+		s.sharedBars = &barRegistry{
+			bars: make(map[string]*liveprogress.Bar),
+		}
+	}
+}
+
+// WithStopHandler sets the function called when a client requests the server to stop (see Client.Stop()).
+// It is called in its own goroutine and can call Server.Close(). Stop requests are ignored without it.
+func WithStopHandler(handler func()) ServerOption {
+	return func(s *Server) {
+		s.stopHandler = handler
+	}
+}
+
+// DefaultBarOptions returns the default bar options of the servers, see WithBarOptions().
+func DefaultBarOptions(spec BarSpec) (opts []liveprogress.BarOption) {
+	if spec.Name != "" {
+		name := spec.Name + " "
+		opts = append(opts, liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string { return name }))
+	}
+	return append(opts, liveprogress.WithAppendPercent(liveprogress.BaseStyle()))
+}
+
+// Server renders the bars of its clients in the live progress. Use Listen() to create it.
+type Server struct {
+	listener    net.Listener
+	path        string
+	tempDir     string
+	barOptions  func(spec BarSpec) []liveprogress.BarOption
+	sharedBars  *barRegistry
+	stopHandler func()
+	conns       map[net.Conn]struct{}
+	closed      bool
+	access      sync.Mutex
+	handlers    sync.WaitGroup
+}
+
+// Listen creates a server listening on the Unix socket at path (a path within a new temporary directory if path is empty)
+// and sets the EnvSocket environment variable of the current process to it, so child processes inherit it.
+// The live progress should be started (see liveprogress.Start()) for the clients bars to be shown.
+func Listen(path string, opts ...ServerOption) (s *Server, err error) {
+	s = &Server{
+		barOptions: DefaultBarOptions,
+		conns:      make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if path == "" {
+		if s.tempDir, err = os.MkdirTemp("", "liveprogress"); err != nil {
+			return nil, fmt.Errorf("failed to create socket directory: %w", err)
+		}
+		path = filepath.Join(s.tempDir, "socket")
+	}
+	if s.listener, err = net.Listen("unix", path); err != nil {
+		if s.tempDir != "" {
+			os.RemoveAll(s.tempDir)
+		}
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+	s.path = path
+	if err = os.Setenv(EnvSocket, path); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("failed to set %s environment variable: %w", EnvSocket, err)
+	}
+	s.handlers.Add(1)
+	go s.accept()
+	return
+}
+
+// Path returns the path of the server Unix socket.
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Env returns the environment variable announcing the server ("LIVEPROGRESS_SOCKET=<path>"), for example to add it to an exec.Cmd Env.
+func (s *Server) Env() string {
+	return EnvSocket + "=" + s.path
+}
+
+// Close stops the server: it stops listening, disconnects the clients (removing their bars, shared or not), waits for their messages
+// to be handled and unsets the EnvSocket environment variable.
+func (s *Server) Close() (err error) {
+	s.access.Lock()
+	if s.closed {
+		s.access.Unlock()
+		return
+	}
+	s.closed = true
+	err = s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.access.Unlock()
+	s.handlers.Wait()
+	if s.sharedBars != nil {
+		s.sharedBars.removeAll()
+	}
+	if os.Getenv(EnvSocket) == s.path {
+		os.Unsetenv(EnvSocket)
+	}
+	if s.tempDir != "" {
+		os.RemoveAll(s.tempDir)
+	}
+	return
+}
+
+func (s *Server) accept() {
+	defer s.handlers.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		s.access.Lock()
+		if s.closed {
+			s.access.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.handlers.Add(1)
+		s.access.Unlock()
+		go s.handle(conn)
+	}
+}
+
+// barRegistry holds bars by their keys, for a connection or for all of them (see WithSharedBars()).
+type barRegistry struct {
+	bars   map[string]*liveprogress.Bar
+	access sync.Mutex
+}
+
+func (br *barRegistry) get(key string) (bar *liveprogress.Bar, found bool) {
+	defer br.access.Unlock()
+	br.access.Lock()
+	bar, found = br.bars[key]
+	return
+}
+
+// set registers bar as key, removing the bar previously registered as key (if any) from the live progress.
+func (br *barRegistry) set(key string, bar *liveprogress.Bar) {
+	defer br.access.Unlock()
+	br.access.Lock()
+	if previous, found := br.bars[key]; found {
+		liveprogress.RemoveBar(previous)
+	}
+	br.bars[key] = bar
+}
+
+// remove removes the bar registered as key from the registry and from the live progress.
+func (br *barRegistry) remove(key string) {
+	defer br.access.Unlock()
+	br.access.Lock()
+	if bar, found := br.bars[key]; found {
+		liveprogress.RemoveBar(bar)
+		delete(br.bars, key)
+	}
+}
+
+// removeAll removes all the bars of the registry from the live progress.
+func (br *barRegistry) removeAll() {
+	defer br.access.Unlock()
+	br.access.Lock()
+	for key, bar := range br.bars {
+		liveprogress.RemoveBar(bar)
+		delete(br.bars, key)
+	}
+}
+
+// session is the state of a client connection.
+type session struct {
+	server  *Server
+	bars    *barRegistry
+	pending bytes.Buffer // incomplete bypass line
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.handlers.Done()
+	sess := &session{
+		server: s,
+		bars:   s.sharedBars,
+	}
+	if sess.bars == nil {
+		sess.bars = &barRegistry{
+			bars: make(map[string]*liveprogress.Bar),
+		}
+	}
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxMessageSize)
+	for scanner.Scan() {
+		var msg Message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			// not speaking our protocol
+			break
+		}
+		sess.apply(msg)
+	}
+	// cleanup
+	s.access.Lock()
+	delete(s.conns, conn)
+	s.access.Unlock()
+	conn.Close()
+	if sess.pending.Len() > 0 {
+		sess.pending.WriteByte('\n')
+		_, _ = liveprogress.Bypass().Write(sess.pending.Bytes())
+	}
+	if s.sharedBars == nil {
+		sess.bars.removeAll()
+	}
+}
+
+func (sess *session) apply(msg Message) {
+	switch msg.Op {
+	case OpAddBar:
+		spec := BarSpec{
+			Name:  msg.Name,
+			Group: msg.Group,
+			Total: msg.Total,
+		}
+		opts := append(sess.server.barOptions(spec), liveprogress.WithName(spec.Name), liveprogress.WithGroup(spec.Group))
+		if spec.Total > 0 {
+			opts = append(opts, liveprogress.WithTotal(spec.Total))
+		}
+		sess.bars.set(msg.Bar, liveprogress.AddBar(opts...))
+		return
+	case OpBypass:
+		sess.pending.WriteString(msg.Text)
+		if lastNewLine := bytes.LastIndexByte(sess.pending.Bytes(), '\n'); lastNewLine >= 0 {
+			_, _ = liveprogress.Bypass().Write(sess.pending.Next(lastNewLine + 1))
+		}
+		return
+	case OpRemoveBar:
+		sess.bars.remove(msg.Bar)
+		return
+	case OpStop:
+		if sess.server.stopHandler != nil {
+			go sess.server.stopHandler()
+		}
+		return
+	}
+	bar, found := sess.bars.get(msg.Bar)
+	if !found {
+		return
+	}
+	switch msg.Op {
+	case OpCurrentAdd:
+		bar.CurrentAdd(msg.Value)
+	case OpCurrentSet:
+		bar.CurrentSet(msg.Value)
+	case OpComplete:
+		bar.Complete()
+	case OpAbort:
+		bar.Abort()
+	}
+}
@@ -0,0 +1,116 @@
+// Package liveprogresstea adapts liveprogress bars and custom lines to Bubble Tea (https://github.com/charmbracelet/bubbletea) applications.
+package liveprogresstea
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hekmon/liveprogress/v2"
+)
+
+var (
+	lastID atomic.Int64
+)
+
+// TickMsg is the message sent at each refresh interval to the model that requested it.
+type TickMsg struct {
+	Time time.Time
+	id   int64
+}
+
+// Option is a function that can be used to configure a model at creation, see New().
+type Option func(*Model)
+
+// WithRefreshInterval sets the time between each refresh of the model. By default liveprogress.RefreshInterval is used.
+// Set it to 0 to disable the refresh ticks: the model will then only be rendered when your application is.
+func WithRefreshInterval(interval time.Duration) Option {
+	return func(m *Model) {
+		m.interval = interval
+	}
+}
+
+// WithWidth sets a fixed width for the rendering of the items. By default the width is updated from tea.WindowSizeMsg.
+func WithWidth(width int) Option {
+	return func(m *Model) {
+		m.width = width
+		m.fixedWidth = true
+	}
+}
+
+// Model is a tea.Model rendering liveprogress items (bars and custom lines created with liveprogress.NewBar() and
+// liveprogress.NewCustomLine()) exactly as liveprogress would render them on a terminal (see liveprogress.RenderItems()).
+// Items values are updated by your code as usual: the model only renders them.
+type Model struct {
+	id         int64
+	items      []fmt.Stringer
+	interval   time.Duration
+	width      int
+	fixedWidth bool
+}
+
+// New returns a model rendering items, configured with opts.
+func New(items []fmt.Stringer, opts ...Option) (m Model) {
+	m = Model{
+		id:       lastID.Add(1),
+		items:    items,
+		interval: liveprogress.RefreshInterval,
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return
+}
+
+// Items returns the items rendered by the model.
+func (m Model) Items() []fmt.Stringer {
+	return m.items
+}
+
+// SetItems returns a copy of the model rendering items instead of its current ones.
+func (m Model) SetItems(items ...fmt.Stringer) Model {
+	m.items = items
+	return m
+}
+
+// Init implements tea.Model. It starts the refresh ticks.
+func (m Model) Init() tea.Cmd {
+	return m.Tick()
+}
+
+// Update implements tea.Model. It handles tea.WindowSizeMsg for automatic width bars and its own TickMsg.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		if !m.fixedWidth {
+			m.width = msg.Width
+		}
+	case TickMsg:
+		if msg.id == m.id {
+			return m, m.Tick()
+		}
+	}
+	return m, nil
+}
+
+// View implements tea.Model.
+func (m Model) View() string {
+	return strings.Join(liveprogress.RenderItems(m.width, m.items...), "\n")
+}
+
+// Tick returns the command producing the next TickMsg of this model, or nil if refresh ticks are disabled.
+// Init() already starts the ticks: only use it if you are embedding the model without calling its Init().
+func (m Model) Tick() tea.Cmd {
+	if m.interval <= 0 {
+		return nil
+	}
+	id := m.id
+	return tea.Tick(m.interval, func(t time.Time) tea.Msg {
+		return TickMsg{
+			Time: t,
+			id:   id,
+		}
+	})
+}
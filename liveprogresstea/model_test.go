@@ -0,0 +1,52 @@
+package liveprogresstea_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstea"
+	"github.com/muesli/termenv"
+)
+
+func TestModelWidth(t *testing.T) {
+	t.Cleanup(liveprogress.ResetColorProfile)
+	liveprogress.SetColorProfile(termenv.Ascii)
+	items := []fmt.Stringer{liveprogress.NewBar(liveprogress.WithASCIIRunes())}
+	for _, test := range []struct {
+		name     string
+		opts     []liveprogresstea.Option
+		expected int
+	}{
+		{name: "automatic", expected: 20},
+		{name: "fixed", opts: []liveprogresstea.Option{liveprogresstea.WithWidth(30)}, expected: 30},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var model tea.Model = liveprogresstea.New(items, test.opts...)
+			model, _ = model.Update(tea.WindowSizeMsg{Width: 20, Height: 10})
+			if view := model.View(); len(view) != test.expected {
+				t.Errorf("expected a view of width %d, got %d: %q", test.expected, len(view), view)
+			}
+		})
+	}
+}
+
+func TestModelTick(t *testing.T) {
+	model := liveprogresstea.New(nil, liveprogresstea.WithRefreshInterval(time.Millisecond))
+	other := liveprogresstea.New(nil, liveprogresstea.WithRefreshInterval(time.Millisecond))
+	tick := model.Init()()
+	if _, ok := tick.(liveprogresstea.TickMsg); !ok {
+		t.Fatalf("expected a TickMsg, got %T", tick)
+	}
+	if _, cmd := other.Update(tick); cmd != nil {
+		t.Error("tick of another model should be ignored")
+	}
+	if _, cmd := model.Update(tick); cmd == nil {
+		t.Error("own tick should schedule the next one")
+	}
+	if cmd := liveprogresstea.New(nil, liveprogresstea.WithRefreshInterval(0)).Init(); cmd != nil {
+		t.Error("ticks should be disabled with a refresh interval of 0")
+	}
+}
@@ -0,0 +1,106 @@
+package liveprogresstest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+)
+
+// FakeClock is a liveprogress.Clock whose time only moves when Advance() or Set() is called.
+// Use it with liveprogress.WithClock(), liveprogress.WithSpinnerClock() or liveprogress.DefaultClock.
+type FakeClock struct {
+	now     time.Time
+	tickers []*fakeTicker
+	access  sync.Mutex
+}
+
+// NewFakeClock returns a fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		now: now,
+	}
+}
+
+// Now returns the current time of the fake clock.
+func (fc *FakeClock) Now() time.Time {
+	defer fc.access.Unlock()
+	fc.access.Lock()
+	return fc.now
+}
+
+// Since returns the time elapsed since t according to the fake clock.
+func (fc *FakeClock) Since(t time.Time) time.Duration {
+	return fc.Now().Sub(t)
+}
+
+// NewTicker returns a ticker that ticks each time the fake clock is advanced past one of its periods.
+// As time.Ticker, it drops ticks if its channel is not read fast enough.
+func (fc *FakeClock) NewTicker(d time.Duration) liveprogress.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+	defer fc.access.Unlock()
+	fc.access.Lock()
+	ticker := &fakeTicker{
+		clock:  fc,
+		period: d,
+		next:   fc.now.Add(d),
+		c:      make(chan time.Time, 1),
+	}
+	fc.tickers = append(fc.tickers, ticker)
+	return ticker
+}
+
+// Advance moves the fake clock forward by d, firing the tickers accordingly.
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.Set(fc.Now().Add(d))
+}
+
+// Set sets the fake clock to now, firing the tickers accordingly. Time can not go backward: an earlier now is ignored.
+func (fc *FakeClock) Set(now time.Time) {
+	defer fc.access.Unlock()
+	fc.access.Lock()
+	if now.Before(fc.now) {
+		return
+	}
+	fc.now = now
+	for _, ticker := range fc.tickers {
+		if ticker.next.After(now) {
+			continue
+		}
+		select {
+		case ticker.c <- now:
+		default:
+		}
+		for !ticker.next.After(now) {
+			ticker.next = ticker.next.Add(ticker.period)
+		}
+	}
+}
+
+func (fc *FakeClock) removeTicker(ticker *fakeTicker) {
+	defer fc.access.Unlock()
+	fc.access.Lock()
+	for index, registered := range fc.tickers {
+		if registered == ticker {
+			fc.tickers = append(fc.tickers[:index], fc.tickers[index+1:]...)
+			return
+		}
+	}
+}
+
+type fakeTicker struct {
+	clock  *FakeClock
+	period time.Duration
+	next   time.Time
+	c      chan time.Time
+}
+
+func (ft *fakeTicker) C() <-chan time.Time {
+	return ft.c
+}
+
+func (ft *fakeTicker) Stop() {
+	ft.clock.removeTicker(ft)
+}
@@ -0,0 +1,31 @@
+package liveprogresstest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	ticker := clock.NewTicker(time.Second)
+	defer ticker.Stop()
+	clock.Advance(500 * time.Millisecond)
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker should not have ticked yet")
+	default:
+	}
+	clock.Advance(3 * time.Second)
+	select {
+	case tick := <-ticker.C():
+		if expected := start.Add(3500 * time.Millisecond); !tick.Equal(expected) {
+			t.Errorf("got tick at %s, expected %s", tick, expected)
+		}
+	default:
+		t.Fatal("ticker should have ticked")
+	}
+	if elapsed := clock.Since(start); elapsed != 3500*time.Millisecond {
+		t.Errorf("unexpected elapsed time: %s", elapsed)
+	}
+}
@@ -0,0 +1,103 @@
+// Package liveprogresstest provides tools to test liveprogress based user interfaces without a real terminal:
+// an in-memory terminal emulator (see Terminal) and a harness running liveprogress on it, drawing frames on demand (see Harness).
+package liveprogresstest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/muesli/termenv"
+)
+
+var (
+	updateGolden = flag.Bool("update-golden", false, "rewrite liveprogresstest golden files with the current screens")
+)
+
+// Harness runs liveprogress on a Terminal: the items (bars and custom lines registered with AddBar(), AddCustomLine(), etc...)
+// are drawn by liveprogress itself, exactly as on a real terminal. As the automatic refreshes are disabled (RefreshInterval of 0),
+// frames are only drawn when Step() is called.
+type Harness struct {
+	Terminal *Terminal
+}
+
+// New starts liveprogress with a new Terminal of cols columns and rows lines as its Output. Styles are disabled
+// (see liveprogress.SetColorProfile()) to keep frames independent of the environment. At the end of the test,
+// liveprogress is stopped and its output configuration and color profile are restored.
+func New(tb testing.TB, cols, rows int) (h *Harness) {
+	tb.Helper()
+	h = &Harness{
+		Terminal: NewTerminal(cols, rows),
+	}
+	output, isTerminal, size, interval := liveprogress.Output, liveprogress.OutputIsTerminal, liveprogress.OutputSize, liveprogress.RefreshInterval
+	tb.Cleanup(liveprogress.ResetColorProfile)
+	tb.Cleanup(func() {
+		_ = liveprogress.Stop(false)
+		liveprogress.Output = output
+		liveprogress.OutputIsTerminal = isTerminal
+		liveprogress.OutputSize = size
+		liveprogress.RefreshInterval = interval
+	})
+	liveprogress.Output = h.Terminal
+	liveprogress.OutputIsTerminal = func() bool { return true }
+	liveprogress.OutputSize = h.Terminal.Size
+	liveprogress.RefreshInterval = 0
+	liveprogress.SetColorProfile(termenv.Ascii)
+	if err := liveprogress.Start(); err != nil {
+		tb.Fatalf("failed to start liveprogress: %s", err)
+	}
+	return
+}
+
+// Step draws a new frame of the current liveprogress items over the previous one, see liveprogress.Tick().
+func (h *Harness) Step() {
+	liveprogress.Tick()
+}
+
+// Bypass writes p above the live area, see liveprogress.Bypass().
+func (h *Harness) Bypass(p []byte) {
+	_, _ = liveprogress.Bypass().Write(p)
+}
+
+// Clear stops liveprogress erasing the last frame, see liveprogress.Stop().
+func (h *Harness) Clear() {
+	_ = liveprogress.Stop(true)
+}
+
+// Screen returns the current content of the terminal, see Terminal.String().
+func (h *Harness) Screen() string {
+	return h.Terminal.String()
+}
+
+// AssertGolden compares the current content of the terminal with the content of the testdata/<name>.golden file
+// and marks the test as failed if they differ. Run the tests with the -update-golden flag to (re)write the golden files.
+func (h *Harness) AssertGolden(tb testing.TB, name string) {
+	tb.Helper()
+	AssertGolden(tb, name, h.Screen())
+}
+
+// AssertGolden compares screen with the content of the testdata/<name>.golden file and marks the test as failed if they differ.
+// Run the tests with the -update-golden flag to (re)write the golden files.
+func AssertGolden(tb testing.TB, name, screen string) {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("failed to create golden file directory: %s", err)
+		}
+		if err := os.WriteFile(path, []byte(screen+"\n"), 0o644); err != nil {
+			tb.Fatalf("failed to write golden file: %s", err)
+		}
+		return
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("failed to read golden file (run with -update-golden to create it): %s", err)
+	}
+	if got := []byte(screen + "\n"); !bytes.Equal(got, expected) {
+		tb.Errorf("screen does not match golden file %s\n--- got ---\n%s--- expected ---\n%s", path, got, expected)
+	}
+}
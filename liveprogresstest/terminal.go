@@ -0,0 +1,341 @@
+package liveprogresstest
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+type parserState int
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateCSI
+	stateOSC
+	stateOSCEscape
+)
+
+// Terminal is an in-memory terminal emulator of a fixed size. It implements io.Writer and interprets
+// the subset of escape sequences emitted by liveprogress: cursor movements, line and display
+// erasing, cursor visibility. Styling sequences (SGR) and operating system commands (OSC) are parsed and ignored.
+// Like a terminal in cooked mode, '\n' moves the cursor to the beginning of the next line.
+// Lines scrolled out of the top of the screen are kept, see Scrollback().
+type Terminal struct {
+	cols, rows int
+	cells      [][]string // a wide rune is stored in its first cell, the following one is empty
+	scrollback []string
+	// cursor
+	row, col      int
+	wrapPending   bool
+	cursorVisible bool
+	// parser
+	state     parserState
+	params    strings.Builder
+	remainder []byte // incomplete UTF-8 sequence from the previous write
+	access    sync.Mutex
+}
+
+// NewTerminal returns a blank terminal of cols columns and rows lines with its cursor visible at the top left corner.
+func NewTerminal(cols, rows int) (t *Terminal) {
+	if cols < 1 {
+		cols = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	t = &Terminal{
+		cols:          cols,
+		rows:          rows,
+		cells:         make([][]string, rows),
+		cursorVisible: true,
+	}
+	for row := range t.cells {
+		t.cells[row] = t.blankLine()
+	}
+	return
+}
+
+// Size returns the number of columns and lines of the terminal.
+func (t *Terminal) Size() (cols, rows int) {
+	return t.cols, t.rows
+}
+
+// Write interprets p as it would have been written to a real terminal. It never fails.
+func (t *Terminal) Write(p []byte) (n int, err error) {
+	defer t.access.Unlock()
+	t.access.Lock()
+	n = len(p)
+	if len(t.remainder) > 0 {
+		p = append(t.remainder, p...)
+		t.remainder = nil
+	}
+	for len(p) > 0 {
+		r, size := utf8.DecodeRune(p)
+		if r == utf8.RuneError && !utf8.FullRune(p) {
+			t.remainder = append([]byte(nil), p...)
+			return
+		}
+		t.interpret(r)
+		p = p[size:]
+	}
+	return
+}
+
+// Screen returns the visible lines of the terminal, without trailing spaces.
+func (t *Terminal) Screen() (lines []string) {
+	defer t.access.Unlock()
+	t.access.Lock()
+	lines = make([]string, t.rows)
+	for row := range t.cells {
+		lines[row] = t.lineString(row)
+	}
+	return
+}
+
+// String returns the visible lines of the terminal joined by '\n', without trailing spaces nor trailing empty lines.
+func (t *Terminal) String() string {
+	lines := t.Screen()
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Scrollback returns the lines that have been scrolled out of the top of the screen, oldest first.
+func (t *Terminal) Scrollback() []string {
+	defer t.access.Unlock()
+	t.access.Lock()
+	return append([]string(nil), t.scrollback...)
+}
+
+// Cursor returns the current position (0 based) of the cursor.
+func (t *Terminal) Cursor() (row, col int) {
+	defer t.access.Unlock()
+	t.access.Lock()
+	return t.row, t.col
+}
+
+// CursorVisible returns false if the cursor has been hidden.
+func (t *Terminal) CursorVisible() bool {
+	defer t.access.Unlock()
+	t.access.Lock()
+	return t.cursorVisible
+}
+
+/*
+	Parser
+*/
+
+func (t *Terminal) interpret(r rune) {
+	switch t.state {
+	case stateEscape:
+		switch r {
+		case '[':
+			t.state = stateCSI
+			t.params.Reset()
+		case ']':
+			t.state = stateOSC
+		default:
+			// unsupported two characters sequence (ESC 7, ESC 8, etc...)
+			t.state = stateGround
+		}
+	case stateCSI:
+		if r >= 0x40 && r <= 0x7e {
+			t.state = stateGround
+			t.csi(r, t.params.String())
+		} else {
+			t.params.WriteRune(r)
+		}
+	case stateOSC:
+		switch r {
+		case '\a':
+			t.state = stateGround
+		case 0x1b:
+			t.state = stateOSCEscape
+		}
+	case stateOSCEscape:
+		// ESC \ (string terminator)
+		t.state = stateGround
+	default:
+		t.ground(r)
+	}
+}
+
+func (t *Terminal) ground(r rune) {
+	switch r {
+	case 0x1b:
+		t.state = stateEscape
+	case '\n':
+		t.col = 0
+		t.lineFeed()
+	case '\r':
+		t.col = 0
+		t.wrapPending = false
+	case '\b':
+		if t.col > 0 {
+			t.col--
+		}
+		t.wrapPending = false
+	case '\t':
+		t.col = clampInt((t.col/8+1)*8, 0, t.cols-1)
+		t.wrapPending = false
+	default:
+		if r < 0x20 || r == 0x7f {
+			// other control characters (NUL included) are not printed
+			return
+		}
+		t.print(r)
+	}
+}
+
+func (t *Terminal) print(r rune) {
+	width := runewidth.RuneWidth(r)
+	if width == 0 {
+		return
+	}
+	if t.wrapPending || t.col+width > t.cols {
+		t.col = 0
+		t.lineFeed()
+	}
+	t.cells[t.row][t.col] = string(r)
+	if width == 2 && t.col+1 < t.cols {
+		t.cells[t.row][t.col+1] = ""
+	}
+	if t.col+width >= t.cols {
+		// stay on the last column until the next printable rune, as real terminals do
+		t.col = t.cols - 1
+		t.wrapPending = true
+	} else {
+		t.col += width
+	}
+}
+
+func (t *Terminal) lineFeed() {
+	t.wrapPending = false
+	if t.row < t.rows-1 {
+		t.row++
+		return
+	}
+	// scroll
+	t.scrollback = append(t.scrollback, t.lineString(0))
+	copy(t.cells, t.cells[1:])
+	t.cells[t.rows-1] = t.blankLine()
+}
+
+func (t *Terminal) csi(final rune, rawParams string) {
+	private := strings.HasPrefix(rawParams, "?")
+	params := parseParams(strings.TrimPrefix(rawParams, "?"))
+	t.wrapPending = false
+	switch final {
+	case 'A':
+		t.row = clampInt(t.row-params.get(0, 1), 0, t.rows-1)
+	case 'B':
+		t.row = clampInt(t.row+params.get(0, 1), 0, t.rows-1)
+	case 'C':
+		t.col = clampInt(t.col+params.get(0, 1), 0, t.cols-1)
+	case 'D':
+		t.col = clampInt(t.col-params.get(0, 1), 0, t.cols-1)
+	case 'E':
+		t.row = clampInt(t.row+params.get(0, 1), 0, t.rows-1)
+		t.col = 0
+	case 'F':
+		t.row = clampInt(t.row-params.get(0, 1), 0, t.rows-1)
+		t.col = 0
+	case 'G':
+		t.col = clampInt(params.get(0, 1)-1, 0, t.cols-1)
+	case 'H', 'f':
+		t.row = clampInt(params.get(0, 1)-1, 0, t.rows-1)
+		t.col = clampInt(params.get(1, 1)-1, 0, t.cols-1)
+	case 'J':
+		t.eraseDisplay(params.get(0, 0))
+	case 'K':
+		t.eraseLine(params.get(0, 0))
+	case 'h', 'l':
+		if private && params.get(0, 0) == 25 {
+			t.cursorVisible = final == 'h'
+		}
+	}
+	// others (SGR included) do not change the screen content
+}
+
+func (t *Terminal) eraseLine(mode int) {
+	from, to := 0, t.cols
+	switch mode {
+	case 0:
+		from = t.col
+	case 1:
+		to = t.col + 1
+	}
+	for col := from; col < to; col++ {
+		t.cells[t.row][col] = " "
+	}
+}
+
+func (t *Terminal) eraseDisplay(mode int) {
+	switch mode {
+	case 0:
+		t.eraseLine(0)
+		for row := t.row + 1; row < t.rows; row++ {
+			t.cells[row] = t.blankLine()
+		}
+	case 1:
+		t.eraseLine(1)
+		for row := 0; row < t.row; row++ {
+			t.cells[row] = t.blankLine()
+		}
+	default:
+		for row := range t.cells {
+			t.cells[row] = t.blankLine()
+		}
+	}
+}
+
+func (t *Terminal) blankLine() (line []string) {
+	line = make([]string, t.cols)
+	for col := range line {
+		line[col] = " "
+	}
+	return
+}
+
+func (t *Terminal) lineString(row int) string {
+	return strings.TrimRight(strings.Join(t.cells[row], ""), " ")
+}
+
+type csiParams []int
+
+func parseParams(raw string) (params csiParams) {
+	if raw == "" {
+		return
+	}
+	for _, field := range strings.Split(raw, ";") {
+		value, err := strconv.Atoi(field)
+		if err != nil {
+			value = -1
+		}
+		params = append(params, value)
+	}
+	return
+}
+
+// get returns the parameter at index or def if it is missing or set to 0 (for movements def is 1).
+func (p csiParams) get(index, def int) int {
+	if index >= len(p) || p[index] <= 0 {
+		return def
+	}
+	return p[index]
+}
+
+func clampInt(value, low, high int) int {
+	if value < low {
+		return low
+	}
+	if value > high {
+		return high
+	}
+	return value
+}
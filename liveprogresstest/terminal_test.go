@@ -0,0 +1,57 @@
+package liveprogresstest
+
+import (
+	"testing"
+)
+
+func TestTerminalWrap(t *testing.T) {
+	term := NewTerminal(4, 3)
+	_, _ = term.Write([]byte("abcdefg\nh"))
+	if got, expected := term.String(), "abcd\nefg\nh"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestTerminalScroll(t *testing.T) {
+	term := NewTerminal(10, 2)
+	_, _ = term.Write([]byte("one\ntwo\nthree"))
+	if got, expected := term.String(), "two\nthree"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+	if scrollback := term.Scrollback(); len(scrollback) != 1 || scrollback[0] != "one" {
+		t.Errorf("unexpected scrollback: %q", scrollback)
+	}
+}
+
+func TestTerminalEscapeSequences(t *testing.T) {
+	term := NewTerminal(10, 3)
+	_, _ = term.Write([]byte("\x1b[?25lfirst\nsecond\x1b[0G\x1b[2K\x1b[1A\x1b[2K\x1b[31mred\x1b[0m\x1b]8;;link\x1b\\"))
+	if got, expected := term.String(), "red"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+	if row, col := term.Cursor(); row != 0 || col != 3 {
+		t.Errorf("unexpected cursor position: %d,%d", row, col)
+	}
+	if term.CursorVisible() {
+		t.Error("cursor should be hidden")
+	}
+}
+
+func TestTerminalWideRunes(t *testing.T) {
+	term := NewTerminal(5, 2)
+	_, _ = term.Write([]byte("ab日本"))
+	if got, expected := term.String(), "ab日\n本"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
+
+func TestTerminalSplitWrites(t *testing.T) {
+	term := NewTerminal(10, 1)
+	sequence := []byte("█\x1b[1m█")
+	for _, b := range sequence {
+		_, _ = term.Write([]byte{b})
+	}
+	if got, expected := term.String(), "██"; got != expected {
+		t.Errorf("got %q, expected %q", got, expected)
+	}
+}
@@ -0,0 +1,142 @@
+package liveprogress
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultMetricsNamespace is the default prefix of the metrics exposed by MetricsHandler().
+	DefaultMetricsNamespace = "liveprogress"
+	prometheusContentType   = "text/plain; version=0.0.4; charset=utf-8"
+	openMetricsContentType  = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// MetricsOption is a function that can be used to configure the handler created by MetricsHandler().
+type MetricsOption func(*metricsHandler)
+
+// WithMetricsNamespace sets the prefix of the exposed metrics names. Default is DefaultMetricsNamespace.
+func WithMetricsNamespace(namespace string) MetricsOption {
+	return func(mh *metricsHandler) {
+		mh.namespace = namespace
+	}
+}
+
+// MetricsHandler returns an http.Handler exposing the bars registered in the live progress (see AddBar() and SetMainLineAsBar())
+// as gauges in the Prometheus text format, or in the OpenMetrics text format if the scraper asks for it.
+// Each bar exposes its current value, total, ratio, rate (see Bar.Rate()), estimated time remaining (NaN until the bar
+// has progressed) and done flag, labeled by its id, name and group (see WithName() and WithGroup()).
+// Bars are read at each scrape: added and removed bars are reflected immediately. The live progress does not need to be started.
+func MetricsHandler(opts ...MetricsOption) http.Handler {
+	mh := &metricsHandler{
+		namespace: DefaultMetricsNamespace,
+	}
+	for _, opt := range opts {
+		opt(mh)
+	}
+	return mh
+}
+
+type metricsHandler struct {
+	namespace string
+}
+
+type barMetric struct {
+	name  string
+	help  string
+	value func(pb *Bar) float64
+}
+
+var barMetrics = []barMetric{
+	{
+		name:  "bar_current",
+		help:  "Current value of the progress bar.",
+		value: func(pb *Bar) float64 { return float64(pb.Current()) },
+	},
+	{
+		name:  "bar_total",
+		help:  "Total value of the progress bar.",
+		value: func(pb *Bar) float64 { return float64(pb.Total()) },
+	},
+	{
+		name:  "bar_ratio",
+		help:  "Progress of the progress bar, between 0 and 1.",
+		value: func(pb *Bar) float64 { return pb.Progress() },
+	},
+	{
+		name:  "bar_rate",
+		help:  "Average progression of the progress bar in units per second.",
+		value: func(pb *Bar) float64 { return pb.Rate() },
+	},
+	{
+		name: "bar_eta_seconds",
+		help: "Estimated time remaining until the progress bar completion in seconds.",
+		value: func(pb *Bar) float64 {
+			remaining, known := pb.Remaining()
+			if !known {
+				return math.NaN()
+			}
+			return remaining.Seconds()
+		},
+	},
+	{
+		name: "bar_done",
+		help: "Whether the progress bar is completed (1) or not (0).",
+		value: func(pb *Bar) float64 {
+			if pb.Completed() {
+				return 1
+			}
+			return 0
+		},
+	},
+}
+
+func (mh *metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	openMetrics := strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text")
+	bars := registeredBars()
+	var body bytes.Buffer
+	for _, metric := range barMetrics {
+		name := metric.name
+		if mh.namespace != "" {
+			name = mh.namespace + "_" + name
+		}
+		body.WriteString("# HELP " + name + " " + metric.help + "\n")
+		body.WriteString("# TYPE " + name + " gauge\n")
+		for _, bar := range bars {
+			body.WriteString(name)
+			body.WriteString(`{id="` + strconv.FormatUint(bar.ID(), 10))
+			body.WriteString(`",name="` + escapeLabelValue(bar.Name()))
+			body.WriteString(`",group="` + escapeLabelValue(bar.Group()))
+			body.WriteString(`"} ` + formatMetricValue(metric.value(bar)) + "\n")
+		}
+	}
+	if openMetrics {
+		body.WriteString("# EOF\n")
+		w.Header().Set("Content-Type", openMetricsContentType)
+	} else {
+		w.Header().Set("Content-Type", prometheusContentType)
+	}
+	_, _ = w.Write(body.Bytes())
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
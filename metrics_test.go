@@ -0,0 +1,65 @@
+package liveprogress_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hekmon/liveprogress/v2"
+)
+
+func scrape(t *testing.T, handler http.Handler, accept string) (contentType, body string) {
+	t.Helper()
+	request := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	if accept != "" {
+		request.Header.Set("Accept", accept)
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	raw, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %s", err)
+	}
+	return recorder.Result().Header.Get("Content-Type"), string(raw)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	handler := liveprogress.MetricsHandler()
+	download := liveprogress.AddBar(liveprogress.WithName("download"), liveprogress.WithGroup("fetch"), liveprogress.WithTotal(200))
+	download.CurrentSet(50)
+	liveprogress.SetMainLineAsBar(liveprogress.WithName(`quoted "main"`)).Complete()
+	contentType, body := scrape(t, handler, "")
+	if !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type: %s", contentType)
+	}
+	for _, expected := range []string{
+		"# TYPE liveprogress_bar_current gauge\n",
+		`name="download",group="fetch"} 50` + "\n",
+		`name="download",group="fetch"} 200` + "\n",
+		`name="download",group="fetch"} 0.25` + "\n",
+		`liveprogress_bar_done{id="`,
+		`name="quoted \"main\"",group=""} 1` + "\n",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("metrics do not contain %q:\n%s", expected, body)
+		}
+	}
+	if strings.Contains(body, "# EOF") {
+		t.Error("prometheus format should not contain an EOF marker")
+	}
+	// removal and OpenMetrics format
+	liveprogress.RemoveBar(download)
+	contentType, body = scrape(t, handler, "application/openmetrics-text; version=1.0.0")
+	if !strings.HasPrefix(contentType, "application/openmetrics-text") {
+		t.Errorf("unexpected content type: %s", contentType)
+	}
+	if strings.Contains(body, "download") {
+		t.Errorf("removed bar is still exported:\n%s", body)
+	}
+	if !strings.HasSuffix(body, "# EOF\n") {
+		t.Errorf("openmetrics format should end with an EOF marker:\n%s", body)
+	}
+}
@@ -4,15 +4,21 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/hekmon/liveterm/v2"
 	"github.com/mattn/go-runewidth"
 	"github.com/muesli/ansi"
 	"github.com/muesli/termenv"
 )
 
+var (
+	SpinnerStallTimeout = 3 * time.Second  // SpinnerStallTimeout is the time without update after which a bar spinner (see WithAppendSpinner()) is considered stalled and stops animating.
+	RateWindow          = 10 * time.Second // RateWindow is the period over which a bar rate is computed, see Bar.Rate().
+	PausedMarker        = "‖ "             // PausedMarker is shown before the prepend decorators of a paused bar, see Bar.Pause(). Set it empty to disable it.
+)
+
 const (
 	DefaultTotal         = 100 // DefaultTotal is the default total value of a progress bar. See WithTotal() to change a bar total at creation.
 	minimumProgressWidth = 8
@@ -37,6 +43,38 @@ func WithWidth(width int) BarOption {
 	}
 }
 
+// WithClock sets the clock used by the progress bar for its creation and update times (and so its time based decorators).
+// By default DefaultClock is used.
+func WithClock(clock Clock) BarOption {
+	return func(pb *Bar) {
+		if clock != nil {
+			pb.clock = clock
+		}
+	}
+}
+
+// WithName sets the name of the progress bar, used to identify it outside of the terminal (see MetricsHandler()).
+func WithName(name string) BarOption {
+	return func(pb *Bar) {
+		pb.name = name
+	}
+}
+
+// WithGroup sets the group of the progress bar, used to aggregate bars outside of the terminal (see MetricsHandler()).
+func WithGroup(group string) BarOption {
+	return func(pb *Bar) {
+		pb.group = group
+	}
+}
+
+// WithStartOnFirstProgress delays the start of the progress bar active time (see ActiveDuration()) until its first
+// update, instead of its creation. Use it for bars created ahead of their work, waiting in a queue for example.
+func WithStartOnFirstProgress() BarOption {
+	return func(pb *Bar) {
+		pb.startOnFirstProgress = true
+	}
+}
+
 // WithInternalPadding sets the padding to be internal instead of external for left and right decorators.
 // Only usefull if WithSameAutoSize() has been set too.
 func WithSameAutoSizeInternalPadding(left, right bool) BarOption {
@@ -107,7 +145,18 @@ func WithBarStyle(style termenv.Style) BarOption {
 	}
 }
 
-// DecoratorFunc is a function that can be used to decorate the progress bar.
+// WithBarEmptyStyle sets the style of the empty part of the progress bar.
+// By default the empty part shares the style set by WithBarStyle().
+func WithBarEmptyStyle(style termenv.Style) BarOption {
+	return func(pb *Bar) {
+		pb.barEmptyStyle = style
+		pb.barEmptyStyled = true
+	}
+}
+
+// DecoratorFunc is a function that can be used to decorate the progress bar. Decorators are called by the refreshes, Render(),
+// RenderItems() and Snapshot() one call at a time, but concurrently with your other goroutines (and Bar.String() calls):
+// the state they read must be safe for concurrent use.
 type DecoratorFunc func(pb *Bar) string
 
 // WithAppendDecorator adds a decorator function to the end of the progress bar.
@@ -150,47 +199,47 @@ func getPercent(progress float64) (percent string) {
 	return fmt.Sprintf("%3d%%", percentInt)
 }
 
-// WithPrependTimeElapsed adds the time elapsed since the creation of the progress bar to the beginning of the bar.
+// WithPrependTimeElapsed adds the active time of the progress bar (see ActiveDuration()) to the beginning of the bar.
 // Use BaseStyle() if you do not want any particular style.
 func WithPrependTimeElapsed(style termenv.Style) BarOption {
 	return WithPrependDecorator(func(pb *Bar) string {
-		return style.Styled(getTimeElapsed(pb.GetCreationTime())) + " "
+		return style.Styled(getTimeElapsed(pb.ActiveDuration())) + " "
 	})
 }
 
-// WithAppendTimeElapsed adds the time elapsed since the creation of the progress bar to the end of the bar.
+// WithAppendTimeElapsed adds the active time of the progress bar (see ActiveDuration()) to the end of the bar.
 // Use BaseStyle() if you do not want any particular style.
 func WithAppendTimeElapsed(style termenv.Style) BarOption {
 	return WithAppendDecorator(func(pb *Bar) string {
-		return " " + style.Styled(getTimeElapsed(pb.GetCreationTime()))
+		return " " + style.Styled(getTimeElapsed(pb.ActiveDuration()))
 	})
 }
 
-func getTimeElapsed(start time.Time) string {
-	return time.Since(start).Round(time.Second).String()
+func getTimeElapsed(elapsed time.Duration) string {
+	return elapsed.Round(time.Second).String()
 }
 
-// WithPrependTimeRemaining adds the time remaining until the end of the progress bar to the beginning of the bar.
+// WithPrependTimeRemaining adds the time remaining until the end of the progress bar (see Remaining()) to the beginning of the bar.
 // Use BaseStyle() if you do not want any particular style.
 func WithPrependTimeRemaining(style termenv.Style) BarOption {
 	return WithPrependDecorator(func(pb *Bar) string {
-		return style.Styled(getRemainingTime(pb.GetCreationTime(), pb.Progress())) + " "
+		return style.Styled(getRemainingTime(pb.ActiveDuration(), pb.Progress())) + " "
 	})
 }
 
-// WithAppendTimeRemaining adds the time remaining until the end of the progress bar to the end of the bar.
+// WithAppendTimeRemaining adds the time remaining until the end of the progress bar (see Remaining()) to the end of the bar.
 // Use BaseStyle() if you do not want any particular style.
 func WithAppendTimeRemaining(style termenv.Style) BarOption {
 	return WithAppendDecorator(func(pb *Bar) string {
-		return " " + style.Styled(getRemainingTime(pb.GetCreationTime(), pb.Progress()))
+		return " " + style.Styled(getRemainingTime(pb.ActiveDuration(), pb.Progress()))
 	})
 }
 
-func getRemainingTime(start time.Time, progress float64) string {
-	if progress == 0 {
+func getRemainingTime(elapsed time.Duration, progress float64) string {
+	timeLeft, known := remainingDuration(elapsed, progress)
+	if !known {
 		return "∞"
 	}
-	timeLeft := time.Duration((1 - progress) * (float64(time.Since(start)) / progress))
 	if timeLeft < time.Minute {
 		return "~" + timeLeft.Round(time.Second).String()
 	}
@@ -203,6 +252,73 @@ func getRemainingTime(start time.Time, progress float64) string {
 	return fmt.Sprintf("~%dh%02dm", hours, minutes)
 }
 
+// remainingDuration estimates the time left from the time elapsed to reach progress. It is unknown without progress.
+func remainingDuration(elapsed time.Duration, progress float64) (remaining time.Duration, known bool) {
+	if progress == 0 {
+		return
+	}
+	if progress >= 1 {
+		return 0, true
+	}
+	return time.Duration((1 - progress) * (float64(elapsed) / progress)), true
+}
+
+// WithPrependSpinner adds an animated spinner to the beginning of the bar. See WithAppendSpinner() for details.
+func WithPrependSpinner(spinner *Spinner, style termenv.Style) BarOption {
+	return func(pb *Bar) {
+		bs := &barSpinner{spinner: spinner, style: style}
+		WithPrependDecorator(func(pb *Bar) string {
+			return bs.render(pb) + " "
+		})(pb)
+	}
+}
+
+// WithAppendSpinner adds an animated spinner to the end of the bar.
+// The spinner animates while the bar is in progress and is frozen while the bar is paused, idle (never updated)
+// or stalled (not updated for more than SpinnerStallTimeout). Once the bar is completed or aborted, the spinner
+// is replaced by its success or failure glyph, see WithSpinnerSuccess() and WithSpinnerFailure().
+// The spinner itself is never paused by its bar: it can be shared by several bars, each one freezing it on its own.
+// Use BaseStyle() if you do not want any particular style.
+func WithAppendSpinner(spinner *Spinner, style termenv.Style) BarOption {
+	return func(pb *Bar) {
+		bs := &barSpinner{spinner: spinner, style: style}
+		WithAppendDecorator(func(pb *Bar) string {
+			return " " + bs.render(pb)
+		})(pb)
+	}
+}
+
+// barSpinner is the state of a spinner decorating a bar: the frame it is frozen on while the bar is not progressing.
+type barSpinner struct {
+	spinner  *Spinner
+	style    termenv.Style
+	frozen   bool
+	frozenAt time.Duration // spinner animation time of the frozen frame
+	access   sync.Mutex
+}
+
+func (bs *barSpinner) render(pb *Bar) string {
+	switch {
+	case pb.Aborted():
+		return bs.spinner.failureGlyph()
+	case pb.Completed():
+		return bs.spinner.successGlyph()
+	}
+	lastUpdate := pb.GetLastUpdateTime()
+	idle := pb.Paused() || lastUpdate.IsZero() || pb.clock.Since(lastUpdate) > SpinnerStallTimeout
+	defer bs.access.Unlock()
+	bs.access.Lock()
+	if !idle {
+		bs.frozen = false
+		return bs.style.Styled(bs.spinner.String())
+	}
+	if !bs.frozen {
+		bs.frozen = true
+		bs.frozenAt = bs.spinner.animationTime()
+	}
+	return bs.style.Styled(bs.spinner.frame(bs.frozenAt))
+}
+
 // BarRunes is the composition of a progress bar.
 type BarRunes struct {
 	LeftEnd  rune
@@ -254,9 +370,12 @@ type barRunesWidth struct {
 	RightEnd int
 }
 
-// Bar is a progress bar that can be added to the live progress. Do not instanciate it directly, use AddBar() instead.
+// Bar is a progress bar that can be added to the live progress. Do not instanciate it directly, use AddBar() or NewBar() instead.
 type Bar struct {
 	// bar config and properties
+	id                   uint64
+	name                 string
+	group                string
 	barWidth             int
 	internalPaddingLeft  bool
 	internalPaddingRight bool
@@ -264,20 +383,48 @@ type Bar struct {
 	barRunesMaxLen       int
 	barRunesWidth        barRunesWidth
 	barStyle             termenv.Style
+	barEmptyStyle        termenv.Style
+	barEmptyStyled       bool
 	// progress values
-	current atomic.Uint64
-	total   uint64
+	current    atomic.Uint64
+	total      uint64
+	lastUpdate atomic.Int64
+	aborted    atomic.Bool
+	// active time
+	startOnFirstProgress bool
+	started              atomic.Bool
+	paused               atomic.Bool
+	pausedBy             pauseSource
+	active               bool // false while paused or not started
+	activeSince          time.Time
+	activeAccumulated    time.Duration
+	activeAccess         sync.Mutex
 	// decorators
-	createdAt    time.Time
-	prependFuncs []DecoratorFunc
-	appendFuncs  []DecoratorFunc
+	clock           Clock
+	createdAt       time.Time
+	restoredElapsed atomic.Int64
+	rateSamples     []rateSample
+	rateAccess      sync.Mutex
+	prependFuncs    []DecoratorFunc
+	appendFuncs     []DecoratorFunc
 }
 
+// pauseSource records who paused a bar: the bar itself (see Bar.Pause()) and/or PauseAll().
+type pauseSource uint8
+
+const (
+	pausedByBar pauseSource = 1 << iota
+	pausedByAll
+)
+
+var lastBarID atomic.Uint64
+
 func newBar(opts ...BarOption) (b *Bar) {
 	// Init base
 	b = &Bar{
+		id:           lastBarID.Add(1),
 		total:        DefaultTotal,
-		createdAt:    time.Now(),
+		clock:        DefaultClock,
 		prependFuncs: make([]DecoratorFunc, 0, len(opts)),
 		appendFuncs:  make([]DecoratorFunc, 0, len(opts)),
 	}
@@ -287,9 +434,31 @@ func newBar(opts ...BarOption) (b *Bar) {
 	for _, opt := range opts {
 		opt(b)
 	}
+	b.createdAt = b.clock.Now()
+	b.rateSamples = []rateSample{{at: b.createdAt}}
+	if !b.startOnFirstProgress {
+		b.started.Store(true)
+		b.active = true
+		b.activeSince = b.createdAt
+	}
 	return
 }
 
+// ID returns the unique identifier of the progress bar within the process, attributed at creation.
+func (pb *Bar) ID() uint64 {
+	return pb.id
+}
+
+// Name returns the name of the progress bar, see WithName().
+func (pb *Bar) Name() string {
+	return pb.name
+}
+
+// Group returns the group of the progress bar, see WithGroup().
+func (pb *Bar) Group() string {
+	return pb.group
+}
+
 // Current returns the current value of the progress bar.
 func (pb *Bar) Current() uint64 {
 	return pb.current.Load()
@@ -298,6 +467,7 @@ func (pb *Bar) Current() uint64 {
 // CurrentAdd adds a value to the current value of the progress bar.
 func (pb *Bar) CurrentAdd(value uint64) {
 	pb.current.Add(value)
+	pb.updated()
 }
 
 // CurrentIncrement increments the current value of the progress bar by 1.
@@ -308,6 +478,61 @@ func (pb *Bar) CurrentIncrement() {
 // CurrentSet sets the current value of the progress bar.
 func (pb *Bar) CurrentSet(value uint64) {
 	pb.current.Store(value)
+	pb.updated()
+}
+
+func (pb *Bar) updated() {
+	now := pb.clock.Now()
+	pb.lastUpdate.Store(now.UnixNano())
+	if !pb.started.Load() {
+		pb.start(now)
+	}
+	requestRefresh()
+}
+
+// start starts the active time of a bar created with WithStartOnFirstProgress().
+func (pb *Bar) start(now time.Time) {
+	defer pb.activeAccess.Unlock()
+	pb.activeAccess.Lock()
+	if pb.started.Load() {
+		return
+	}
+	pb.started.Store(true)
+	if pb.pausedBy == 0 {
+		pb.active = true
+		pb.activeSince = now
+	}
+}
+
+// Complete sets the current value of the progress bar to its total.
+func (pb *Bar) Complete() {
+	pb.CurrentSet(pb.total)
+}
+
+// Completed returns true if the current value of the progress bar has reached its total.
+func (pb *Bar) Completed() bool {
+	return pb.current.Load() >= pb.total
+}
+
+// Abort marks the progress bar as aborted: it will not be considered in progress anymore even if it is not completed.
+func (pb *Bar) Abort() {
+	pb.aborted.Store(true)
+	requestRefresh()
+}
+
+// Aborted returns true if Abort() has been called on the progress bar.
+func (pb *Bar) Aborted() bool {
+	return pb.aborted.Load()
+}
+
+// GetLastUpdateTime returns the time of the last CurrentAdd() or CurrentSet() call (and their derivatives).
+// It returns a zero time if the progress bar has never been updated.
+func (pb *Bar) GetLastUpdateTime() time.Time {
+	lastUpdate := pb.lastUpdate.Load()
+	if lastUpdate == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, lastUpdate)
 }
 
 // GetCreationTime returns the time at which the progress bar was created.
@@ -315,6 +540,110 @@ func (pb *Bar) GetCreationTime() time.Time {
 	return pb.createdAt
 }
 
+// Elapsed returns the time elapsed since the creation of the progress bar, plus the elapsed time restored by LoadState() if any.
+// Unlike ActiveDuration(), it includes the time spent paused or waiting for the first update.
+func (pb *Bar) Elapsed() time.Duration {
+	return time.Duration(pb.restoredElapsed.Load()) + pb.clock.Since(pb.createdAt)
+}
+
+// ActiveDuration returns the time the progress bar has been active: since its creation (or its first update, see
+// WithStartOnFirstProgress()) minus the time spent paused, plus the active time restored by LoadState() if any.
+// It is used by the time elapsed and time remaining decorators.
+func (pb *Bar) ActiveDuration() time.Duration {
+	return time.Duration(pb.restoredElapsed.Load()) + pb.activeDuration()
+}
+
+func (pb *Bar) activeDuration() (active time.Duration) {
+	defer pb.activeAccess.Unlock()
+	pb.activeAccess.Lock()
+	active = pb.activeAccumulated
+	if pb.active {
+		active += pb.clock.Since(pb.activeSince)
+	}
+	return
+}
+
+// Pause stops the active time of the progress bar (see ActiveDuration()) until Resume() is called.
+// A paused bar is marked by PausedMarker and its spinner is frozen. Updating a paused bar does not resume it.
+func (pb *Bar) Pause() {
+	pb.setPaused(pausedByBar, true)
+}
+
+// Resume resumes the active time of a progress bar paused by Pause().
+// It does not resume a bar paused by PauseAll(), see ResumeAll().
+func (pb *Bar) Resume() {
+	pb.setPaused(pausedByBar, false)
+}
+
+// Paused returns true if the progress bar has been paused by Pause() or PauseAll().
+func (pb *Bar) Paused() bool {
+	return pb.paused.Load()
+}
+
+func (pb *Bar) setPaused(source pauseSource, pause bool) {
+	defer pb.activeAccess.Unlock()
+	pb.activeAccess.Lock()
+	wasPaused := pb.pausedBy != 0
+	if pause {
+		pb.pausedBy |= source
+	} else {
+		pb.pausedBy &^= source
+	}
+	switch {
+	case !wasPaused && pb.pausedBy != 0:
+		if pb.active {
+			pb.activeAccumulated += pb.clock.Since(pb.activeSince)
+			pb.active = false
+		}
+	case wasPaused && pb.pausedBy == 0:
+		if pb.started.Load() {
+			pb.active = true
+			pb.activeSince = pb.clock.Now()
+		}
+	}
+	pb.paused.Store(pb.pausedBy != 0)
+	requestRefresh()
+}
+
+// Remaining returns the estimated time left until the progress bar completion (based on its active time, see ActiveDuration()),
+// as shown by the time remaining decorators. known is false as long as the bar has not progressed.
+func (pb *Bar) Remaining() (remaining time.Duration, known bool) {
+	return remainingDuration(pb.ActiveDuration(), pb.Progress())
+}
+
+// Rate returns the average progression of the bar in units per second over the last RateWindow.
+// The bar is sampled each time Rate() is called (a rate decorator samples it at each refresh) at most 10 times per RateWindow.
+func (pb *Bar) Rate() float64 {
+	now := pb.clock.Now()
+	current := pb.current.Load()
+	defer pb.rateAccess.Unlock()
+	pb.rateAccess.Lock()
+	// Sample
+	if len(pb.rateSamples) == 0 || now.Sub(pb.rateSamples[len(pb.rateSamples)-1].at) >= RateWindow/10 {
+		pb.rateSamples = append(pb.rateSamples, rateSample{at: now, value: current})
+	}
+	// Drop samples out of the window but keep the most recent of them as reference
+	var outdated int
+	for outdated < len(pb.rateSamples)-1 && now.Sub(pb.rateSamples[outdated+1].at) >= RateWindow {
+		outdated++
+	}
+	if outdated > 0 {
+		pb.rateSamples = append(pb.rateSamples[:0], pb.rateSamples[outdated:]...)
+	}
+	// Compute
+	reference := pb.rateSamples[0]
+	elapsed := now.Sub(reference.at)
+	if elapsed <= 0 || current < reference.value {
+		return 0
+	}
+	return float64(current-reference.value) / elapsed.Seconds()
+}
+
+type rateSample struct {
+	at    time.Time
+	value uint64
+}
+
 // Progress returns the progress of the bar as a float64 between 0 and 1.
 func (pb *Bar) Progress() float64 {
 	return float64(pb.current.Load()) / float64(pb.total)
@@ -322,8 +651,12 @@ func (pb *Bar) Progress() float64 {
 
 // String returns a naive (does not support the AutoSizeSameSize) string representation of the progress bar.
 func (pb *Bar) String() (line string) {
+	lineWidth, _ := termSize()
+	return pb.render(lineWidth)
+}
+
+func (pb *Bar) render(lineWidth int) (line string) {
 	// Generate line parts
-	lineWidth, _ := liveterm.GetTermSize()
 	pfx, pfxWidth := pb.renderPfx()
 	afx, afxWidth := pb.renderAfx()
 	bar := pb.renderProgressBar(lineWidth, pfxWidth, afxWidth, 0)
@@ -376,6 +709,9 @@ func (pb *Bar) renderAutoSize(pfx, afx string, lineWidth, pfxWidth, pfxPadding,
 
 func (pb *Bar) renderPfx() (pfx string, pfxWidth int) {
 	var builder strings.Builder
+	if pb.Paused() {
+		builder.WriteString(PausedMarker)
+	}
 	for _, fx := range pb.prependFuncs {
 		builder.WriteString(fx(pb))
 	}
@@ -445,11 +781,23 @@ func (pb *Bar) renderProgressBar(lineWidth, pfxWidth, afxWidth, overwriteBarWidt
 		progress.WriteRune(pb.barRunes.Head)
 		completionActualWidth += pb.barRunesWidth.Head
 	}
+	if !pb.barEmptyStyled {
+		// whole bar share the same style
+		for i := 0; i < (barWithinWidth-completionActualWidth)/pb.barRunesWidth.Empty; i++ {
+			progress.WriteRune(pb.barRunes.Empty)
+		}
+		progress.WriteRune(pb.barRunes.RightEnd)
+		return pb.barStyle.Styled(progress.String())
+	}
+	// empty part has its own style
+	var empty strings.Builder
+	empty.Grow(pb.barRunesMaxLen * (barWithinWidth - completionActualWidth))
 	for i := 0; i < (barWithinWidth-completionActualWidth)/pb.barRunesWidth.Empty; i++ {
-		progress.WriteRune(pb.barRunes.Empty)
+		empty.WriteRune(pb.barRunes.Empty)
 	}
-	progress.WriteRune(pb.barRunes.RightEnd)
-	return pb.barStyle.Styled(progress.String())
+	return pb.barStyle.Styled(progress.String()) +
+		pb.barEmptyStyle.Styled(empty.String()) +
+		pb.barStyle.Styled(string(pb.barRunes.RightEnd))
 }
 
 // Total returns the total value of the progress bar.
@@ -0,0 +1,247 @@
+package liveprogress_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+	"github.com/muesli/termenv"
+)
+
+func TestBarFixedWidth(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 40, 5)
+	empty := liveprogress.AddBar(liveprogress.WithWidth(20))
+	half := liveprogress.AddBar(liveprogress.WithWidth(20), liveprogress.WithAppendPercent(liveprogress.BaseStyle()))
+	half.CurrentSet(50)
+	full := liveprogress.AddBar(liveprogress.WithWidth(20), liveprogress.WithPlainRunes())
+	full.Complete()
+	tooSmall := liveprogress.AddBar(liveprogress.WithWidth(2), liveprogress.WithTotal(4))
+	tooSmall.CurrentSet(1)
+	_ = empty
+	harness.Step()
+	harness.AssertGolden(t, "bar_fixed_width")
+}
+
+func TestBarRunes(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 30, 5)
+	for _, runes := range []liveprogress.BarOption{
+		liveprogress.WithASCIIRunes(),
+		liveprogress.WithPlainRunes(),
+		liveprogress.WithLineFillRunes(),
+		liveprogress.WithMultiplyRunes(),
+	} {
+		liveprogress.AddBar(runes, liveprogress.WithTotal(3)).CurrentSet(1)
+	}
+	harness.Step()
+	harness.AssertGolden(t, "bar_runes")
+}
+
+func TestBarAutoSize(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 40, 3)
+	bar := liveprogress.AddBar(liveprogress.WithPrependPercent(liveprogress.BaseStyle()))
+	bar.CurrentSet(25)
+	harness.Step()
+	harness.AssertGolden(t, "bar_auto_size")
+}
+
+func TestBarAutoSizeSameSize(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 40, 5)
+	short := liveprogress.AddBar(
+		liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string { return "a " }),
+	)
+	short.CurrentSet(50)
+	long := liveprogress.AddBar(
+		liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string { return "longer " }),
+		liveprogress.WithAppendPercent(liveprogress.BaseStyle()),
+	)
+	long.CurrentSet(50)
+	internal := liveprogress.AddBar(
+		liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string { return "in " }),
+		liveprogress.WithSameAutoSizeInternalPadding(true, true),
+	)
+	internal.CurrentSet(50)
+	liveprogress.AddCustomLine(func() string { return "custom line" })
+	liveprogress.SetMainLineAsBar(
+		liveprogress.WithPrependDecorator(func(*liveprogress.Bar) string { return "main " }),
+	).Complete()
+	harness.Step()
+	harness.AssertGolden(t, "bar_auto_size_same_size")
+}
+
+func TestFrameRedraw(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 30, 6)
+	first := liveprogress.AddBar(liveprogress.WithAppendPercent(liveprogress.BaseStyle()))
+	second := liveprogress.AddBar(liveprogress.WithAppendPercent(liveprogress.BaseStyle()))
+	harness.Step()
+	first.CurrentSet(100)
+	second.CurrentSet(30)
+	harness.Bypass([]byte("first done\n"))
+	liveprogress.RemoveBar(first)
+	harness.Step()
+	harness.AssertGolden(t, "frame_redraw")
+}
+
+func TestBarTimeDecorators(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 40, 3)
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	bar := liveprogress.AddBar(
+		liveprogress.WithClock(clock),
+		liveprogress.WithWidth(10),
+		liveprogress.WithAppendTimeElapsed(liveprogress.BaseStyle()),
+		liveprogress.WithAppendTimeRemaining(liveprogress.BaseStyle()),
+	)
+	harness.Step()
+	harness.AssertGolden(t, "bar_time_decorators_start")
+	clock.Advance(90 * time.Second)
+	bar.CurrentSet(25)
+	harness.Step()
+	harness.AssertGolden(t, "bar_time_decorators_quarter")
+}
+
+func TestBarPause(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	harness := liveprogresstest.New(t, 40, 3)
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	bar := liveprogress.AddBar(
+		liveprogress.WithClock(clock),
+		liveprogress.WithWidth(10),
+		liveprogress.WithAppendTimeElapsed(liveprogress.BaseStyle()),
+		liveprogress.WithAppendTimeRemaining(liveprogress.BaseStyle()),
+	)
+	clock.Advance(30 * time.Second)
+	bar.CurrentSet(25)
+	bar.Pause()
+	clock.Advance(time.Hour)
+	if !bar.Paused() {
+		t.Fatal("bar should be paused")
+	}
+	if active := bar.ActiveDuration(); active != 30*time.Second {
+		t.Errorf("unexpected active duration while paused: %s", active)
+	}
+	harness.Step()
+	harness.AssertGolden(t, "bar_pause_paused")
+	// paused by both the bar and PauseAll()
+	liveprogress.PauseAll()
+	bar.Resume()
+	if !bar.Paused() {
+		t.Error("bar should stay paused until ResumeAll()")
+	}
+	liveprogress.ResumeAll()
+	clock.Advance(10 * time.Second)
+	if active := bar.ActiveDuration(); active != 40*time.Second {
+		t.Errorf("unexpected active duration once resumed: %s", active)
+	}
+	if remaining, _ := bar.Remaining(); remaining != 120*time.Second {
+		t.Errorf("unexpected remaining time: %s", remaining)
+	}
+	if elapsed := bar.Elapsed(); elapsed != time.Hour+40*time.Second {
+		t.Errorf("unexpected elapsed time: %s", elapsed)
+	}
+	harness.Step()
+	harness.AssertGolden(t, "bar_pause_resumed")
+}
+
+func TestBarStartOnFirstProgress(t *testing.T) {
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	bar := liveprogress.NewBar(liveprogress.WithClock(clock), liveprogress.WithStartOnFirstProgress())
+	clock.Advance(time.Minute)
+	if active := bar.ActiveDuration(); active != 0 {
+		t.Errorf("bar should not be active before its first update: %s", active)
+	}
+	bar.CurrentIncrement()
+	clock.Advance(5 * time.Second)
+	if active := bar.ActiveDuration(); active != 5*time.Second {
+		t.Errorf("unexpected active duration: %s", active)
+	}
+}
+
+func TestBarAddedWhileAllPaused(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	liveprogress.PauseAll()
+	bar := liveprogress.AddBar(liveprogress.WithClock(clock))
+	clock.Advance(time.Minute)
+	if !bar.Paused() || bar.ActiveDuration() != 0 {
+		t.Errorf("a bar added while all the bars are paused should start paused (active for %s)", bar.ActiveDuration())
+	}
+	liveprogress.ResumeAll()
+	clock.Advance(time.Second)
+	if bar.Paused() || bar.ActiveDuration() != time.Second {
+		t.Errorf("the bar should be resumed by ResumeAll() (active for %s)", bar.ActiveDuration())
+	}
+}
+
+// spinnerFrame returns the last word of the bar line, the spinner frame of bars decorated with WithAppendSpinner().
+func spinnerFrame(bar *liveprogress.Bar) string {
+	line := liveprogress.RenderItems(40, bar)[0]
+	return line[strings.LastIndex(line, " ")+1:]
+}
+
+func TestBarSpinner(t *testing.T) {
+	t.Cleanup(liveprogress.ResetColorProfile)
+	liveprogress.SetColorProfile(termenv.Ascii)
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	spinner := liveprogress.NewSpinner(
+		liveprogress.WithSpinnerClock(clock),
+		liveprogress.WithSpinnerFPS(1),
+		liveprogress.WithSpinnerFrames('a', 'b', 'c'),
+	)
+	// both bars share the spinner
+	active := liveprogress.NewBar(liveprogress.WithClock(clock), liveprogress.WithWidth(4), liveprogress.WithAppendSpinner(spinner, liveprogress.BaseStyle()))
+	idle := liveprogress.NewBar(liveprogress.WithClock(clock), liveprogress.WithWidth(4), liveprogress.WithAppendSpinner(spinner, liveprogress.BaseStyle()))
+	assertSpinner := func(bar *liveprogress.Bar, expected string) {
+		t.Helper()
+		if frame := spinnerFrame(bar); frame != expected {
+			t.Errorf("expected spinner frame %q, got %q", expected, frame)
+		}
+	}
+	// animating while updated, frozen while idle
+	assertSpinner(idle, "a")
+	active.CurrentSet(10)
+	for _, expected := range []string{"b", "c", "a"} {
+		clock.Advance(time.Second)
+		active.CurrentAdd(1)
+		assertSpinner(active, expected)
+		assertSpinner(idle, "a")
+	}
+	// stalled
+	clock.Advance(liveprogress.SpinnerStallTimeout + time.Second)
+	assertSpinner(active, "b")
+	clock.Advance(time.Second)
+	assertSpinner(active, "b")
+	active.CurrentAdd(1)
+	assertSpinner(active, "c")
+	// paused bar
+	active.Pause()
+	assertSpinner(active, "c")
+	clock.Advance(time.Second)
+	active.CurrentAdd(1)
+	assertSpinner(active, "c")
+	active.Resume()
+	assertSpinner(active, "a")
+	// paused spinner, not resumed by its bars
+	spinner.Pause()
+	clock.Advance(time.Second)
+	active.CurrentAdd(1)
+	assertSpinner(active, "a")
+	if !spinner.Paused() {
+		t.Error("spinner paused by the user should not be resumed by its bars")
+	}
+	spinner.Resume()
+	clock.Advance(time.Second)
+	active.CurrentAdd(1)
+	assertSpinner(active, "b")
+	// completion and abort
+	active.Complete()
+	assertSpinner(active, liveprogress.DefaultSpinnerSuccess)
+	idle.Abort()
+	assertSpinner(idle, liveprogress.DefaultSpinnerFailure)
+}
@@ -0,0 +1,132 @@
+package liveprogress
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/muesli/termenv"
+)
+
+// SlogHandlerOptions are the options of the handler created by NewSlogHandler().
+type SlogHandlerOptions struct {
+	slog.HandlerOptions
+	// JSON switches the records format from slog text format to slog JSON format.
+	JSON bool
+	// LevelStyles sets the style of the level value of each record (text format only), see colors.SlogLevelStyles().
+	// Levels without a style are printed as is.
+	LevelStyles map[slog.Level]termenv.Style
+}
+
+// NewSlogHandler returns a slog.Handler writing its records above the live progress thru Bypass() while liveprogress
+// is running, and directly to Output otherwise (before Start(), after Stop() or if the live progress is disabled).
+// opts can be nil to use the defaults (text format, no level styles). Styled levels are written first, before the time.
+func NewSlogHandler(opts *SlogHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = &SlogHandlerOptions{}
+	}
+	handlerOpts := opts.HandlerOptions
+	if opts.JSON {
+		return slog.NewJSONHandler(liveWriter{}, &handlerOpts)
+	}
+	if len(opts.LevelStyles) == 0 {
+		return slog.NewTextHandler(liveWriter{}, &handlerOpts)
+	}
+	// slog text handler quotes values containing terminal sequences: the styled level is written by our handler instead
+	sh := &styledLevelHandler{
+		levelStyles: opts.LevelStyles,
+		replaceAttr: handlerOpts.ReplaceAttr,
+		record:      &recordBuffer{},
+	}
+	handlerOpts.ReplaceAttr = func(groups []string, attr slog.Attr) slog.Attr {
+		if sh.replaceAttr != nil {
+			attr = sh.replaceAttr(groups, attr)
+		}
+		if len(groups) == 0 && attr.Key == slog.LevelKey && sh.styledLevel(attr) != "" {
+			return slog.Attr{}
+		}
+		return attr
+	}
+	sh.handler = slog.NewTextHandler(sh.record, &handlerOpts)
+	return sh
+}
+
+// styledLevelHandler is a slog text handler writing the level of its records with their style.
+type styledLevelHandler struct {
+	handler     slog.Handler // text handler without the styled levels, writing to record
+	levelStyles map[slog.Level]termenv.Style
+	replaceAttr func(groups []string, attr slog.Attr) slog.Attr
+	record      *recordBuffer
+}
+
+// recordBuffer holds the record being written by a styledLevelHandler, shared with its derived handlers.
+type recordBuffer struct {
+	access sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (rb *recordBuffer) Write(p []byte) (n int, err error) {
+	return rb.buffer.Write(p)
+}
+
+// Enabled implements slog.Handler.
+func (sh *styledLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return sh.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler: the styled level and the rest of the record are written at once.
+func (sh *styledLevelHandler) Handle(ctx context.Context, record slog.Record) (err error) {
+	defer sh.record.access.Unlock()
+	sh.record.access.Lock()
+	sh.record.buffer.Reset()
+	level := slog.Any(slog.LevelKey, record.Level)
+	if sh.replaceAttr != nil {
+		level = sh.replaceAttr(nil, level)
+	}
+	if styled := sh.styledLevel(level); styled != "" {
+		sh.record.buffer.WriteString(slog.LevelKey + "=" + styled + " ")
+	}
+	if err = sh.handler.Handle(ctx, record); err != nil {
+		return
+	}
+	_, err = liveWriter{}.Write(sh.record.buffer.Bytes())
+	return
+}
+
+// WithAttrs implements slog.Handler.
+func (sh *styledLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *sh
+	derived.handler = sh.handler.WithAttrs(attrs)
+	return &derived
+}
+
+// WithGroup implements slog.Handler.
+func (sh *styledLevelHandler) WithGroup(name string) slog.Handler {
+	derived := *sh
+	derived.handler = sh.handler.WithGroup(name)
+	return &derived
+}
+
+// styledLevel returns the styled value of the level attribute, or an empty string if it has no style.
+func (sh *styledLevelHandler) styledLevel(attr slog.Attr) string {
+	level, ok := attr.Value.Any().(slog.Level)
+	if !ok {
+		return ""
+	}
+	style, found := sh.levelStyles[level]
+	if !found {
+		return ""
+	}
+	return style.Styled(level.String())
+}
+
+// liveWriter writes to Bypass() while liveprogress is running and to Output otherwise.
+type liveWriter struct{}
+
+func (liveWriter) Write(p []byte) (n int, err error) {
+	if running.Load() {
+		return Bypass().Write(p)
+	}
+	return Output.Write(p)
+}
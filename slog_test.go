@@ -0,0 +1,80 @@
+package liveprogress_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/muesli/termenv"
+)
+
+// withoutTime removes the time of the records to keep them deterministic.
+func withoutTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		return slog.Attr{}
+	}
+	return attr
+}
+
+func TestSlogHandler(t *testing.T) {
+	var output bytes.Buffer
+	useOutput(t, &output, nil, nil)
+	liveprogress.SetColorProfile(termenv.ANSI)
+	styles := map[slog.Level]termenv.Style{
+		slog.LevelInfo: liveprogress.BaseStyle().Foreground(termenv.ANSIBlue),
+	}
+	for _, test := range []struct {
+		name     string
+		opts     *liveprogress.SlogHandlerOptions
+		expected string
+	}{
+		{
+			name: "text",
+			opts: &liveprogress.SlogHandlerOptions{
+				HandlerOptions: slog.HandlerOptions{ReplaceAttr: withoutTime},
+			},
+			expected: "level=INFO msg=hello\nlevel=WARN msg=careful sub.key=value\n",
+		},
+		{
+			name: "text with level styles",
+			opts: &liveprogress.SlogHandlerOptions{
+				HandlerOptions: slog.HandlerOptions{ReplaceAttr: withoutTime},
+				LevelStyles:    styles,
+			},
+			expected: "level=\x1b[34mINFO\x1b[0m msg=hello\nlevel=WARN msg=careful sub.key=value\n",
+		},
+		{
+			name: "text with level styles and renamed levels",
+			opts: &liveprogress.SlogHandlerOptions{
+				HandlerOptions: slog.HandlerOptions{ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+					if attr.Key == slog.LevelKey {
+						return slog.String(slog.LevelKey, "custom")
+					}
+					return withoutTime(groups, attr)
+				}},
+				LevelStyles: styles,
+			},
+			expected: "level=custom msg=hello\nlevel=custom msg=careful sub.key=value\n",
+		},
+		{
+			name: "json",
+			opts: &liveprogress.SlogHandlerOptions{
+				HandlerOptions: slog.HandlerOptions{ReplaceAttr: withoutTime},
+				JSON:           true,
+				LevelStyles:    styles,
+			},
+			expected: `{"level":"INFO","msg":"hello"}` + "\n" + `{"level":"WARN","msg":"careful","sub":{"key":"value"}}` + "\n",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			output.Reset()
+			logger := slog.New(liveprogress.NewSlogHandler(test.opts))
+			logger.Info("hello")
+			logger.WithGroup("sub").Warn("careful", "key", "value")
+			if output.String() != test.expected {
+				t.Errorf("unexpected records:\n got %q\nwant %q", output.String(), test.expected)
+			}
+		})
+	}
+}
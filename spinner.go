@@ -1,24 +1,252 @@
 package liveprogress
 
+import (
+	"sync"
+	"time"
+
+	"github.com/muesli/termenv"
+)
+
+const (
+	DefaultSpinnerFPS     = 10  // DefaultSpinnerFPS is the default number of frames per second of a spinner. See WithSpinnerFPS() to change it at creation.
+	DefaultSpinnerSuccess = "✔" // DefaultSpinnerSuccess is the default glyph shown by a bar spinner once its bar is completed. See WithSpinnerSuccess() to change it at creation.
+	DefaultSpinnerFailure = "✖" // DefaultSpinnerFailure is the default glyph shown by a bar spinner once its bar is aborted. See WithSpinnerFailure() to change it at creation.
+)
+
 var (
 	spinnerStates = []rune{'⠋', '⠙', '⠹', '⠸', '⠼', '⠴', '⠦', '⠧', '⠇', '⠏'}
 )
 
-// Spinner is a custom item that can be added as custom DecoratorFunc.
+// SpinnerOption is a function that can be used to configure a spinner at creation, see NewSpinner().
+type SpinnerOption func(*Spinner)
+
+// WithSpinnerFPS sets the number of frames per second of the spinner.
+// Values lower than 1 are ignored.
+func WithSpinnerFPS(fps int) SpinnerOption {
+	return func(s *Spinner) {
+		if fps > 0 {
+			s.fps = fps
+		}
+	}
+}
+
+// WithSpinnerFrames sets the runes used as the spinner animation frames.
+// An empty set of frames is ignored.
+func WithSpinnerFrames(frames ...rune) SpinnerOption {
+	return func(s *Spinner) {
+		if len(frames) > 0 {
+			s.frames = frames
+		}
+	}
+}
+
+// WithSpinnerSuccess sets the glyph and its style shown instead of the spinner once its bar is completed.
+// Only used when the spinner is a bar decorator, see WithAppendSpinner().
+func WithSpinnerSuccess(glyph string, style termenv.Style) SpinnerOption {
+	return func(s *Spinner) {
+		s.success = glyph
+		s.successStyle = &style
+	}
+}
+
+// WithSpinnerFailure sets the glyph and its style shown instead of the spinner once its bar is aborted.
+// Only used when the spinner is a bar decorator, see WithAppendSpinner().
+func WithSpinnerFailure(glyph string, style termenv.Style) SpinnerOption {
+	return func(s *Spinner) {
+		s.failure = glyph
+		s.failureStyle = &style
+	}
+}
+
+// WithSpinnerClock sets the clock used by the spinner to compute its current frame. By default DefaultClock is used.
+func WithSpinnerClock(clock Clock) SpinnerOption {
+	return func(s *Spinner) {
+		if clock != nil {
+			s.clock = clock
+		}
+	}
+}
+
+// Spinner is a custom item that can be added as custom DecoratorFunc or as a custom line generator.
+// Its current frame is computed from the time elapsed since its first use (see WithSpinnerFPS()),
+// not from the number of times it is called: it can safely be used by several lines and goroutines at the same time.
+// The zero value is a valid spinner using the default frames and DefaultSpinnerFPS.
 type Spinner struct {
-	lastShown int
+	frames    []rune
+	fps       int
+	clock     Clock
+	access    sync.Mutex
+	startedAt time.Time
+	pausedAt  time.Time
+	stopped   bool
+	final     string
+	// bar decorator glyphs
+	success      string
+	successStyle *termenv.Style
+	failure      string
+	failureStyle *termenv.Style
 }
 
-// Next returns the next spinner state, call it in a loop to animate the spinner.
-func (s *Spinner) Next() string {
-	s.lastShown++
-	if s.lastShown >= len(spinnerStates) {
-		s.lastShown = 0
+// NewSpinner returns a new spinner configured with opts. Its animation starts right away.
+func NewSpinner(opts ...SpinnerOption) (s *Spinner) {
+	s = &Spinner{}
+	for _, opt := range opts {
+		opt(s)
 	}
-	return string(spinnerStates[s.lastShown])
+	s.startedAt = s.getClock().Now()
+	return
+}
+
+// Next returns the current spinner state. Kept for backward compatibility, it is now an alias of String().
+func (s *Spinner) Next() string {
+	return s.String()
 }
 
-// String implements the fmt.Stringer interface
+// String implements the fmt.Stringer interface. It returns the frame matching the current time,
+// the frozen frame if the spinner is paused or the final value if the spinner has been stopped.
 func (s *Spinner) String() string {
-	return s.Next()
+	defer s.access.Unlock()
+	s.access.Lock()
+	if s.stopped {
+		return s.final
+	}
+	elapsed, animated := s.elapsed()
+	if animated {
+		// the next frame will differ
+		requestRefresh()
+	}
+	return string(s.frameAt(elapsed))
+}
+
+// animationTime returns the current time of the spinner animation, see frame().
+func (s *Spinner) animationTime() time.Duration {
+	defer s.access.Unlock()
+	s.access.Lock()
+	elapsed, _ := s.elapsed()
+	return elapsed
+}
+
+// frame returns the frame of the spinner at the animation time elapsed (see animationTime()),
+// or its final value if the spinner has been stopped.
+func (s *Spinner) frame(elapsed time.Duration) string {
+	defer s.access.Unlock()
+	s.access.Lock()
+	if s.stopped {
+		return s.final
+	}
+	return string(s.frameAt(elapsed))
+}
+
+// elapsed is unsafe ! It must be called within a mutex lock by one of its callers.
+// It returns the animation time of the spinner, frozen (not animated) while the spinner is paused.
+func (s *Spinner) elapsed() (elapsed time.Duration, animated bool) {
+	now := s.getClock().Now()
+	if s.startedAt.IsZero() {
+		s.startedAt = now
+	}
+	if !s.pausedAt.IsZero() {
+		return s.pausedAt.Sub(s.startedAt), false
+	}
+	return now.Sub(s.startedAt), true
+}
+
+func (s *Spinner) getClock() Clock {
+	if s.clock == nil {
+		return DefaultClock
+	}
+	return s.clock
+}
+
+// frameAt is unsafe ! It must be called within a mutex lock by one of its callers
+func (s *Spinner) frameAt(elapsed time.Duration) rune {
+	frames := s.frames
+	if len(frames) == 0 {
+		frames = spinnerStates
+	}
+	fps := s.fps
+	if fps <= 0 {
+		fps = DefaultSpinnerFPS
+	}
+	frameDuration := time.Second / time.Duration(fps)
+	if frameDuration <= 0 {
+		frameDuration = 1
+	}
+	if elapsed < 0 {
+		// clock replaced by an earlier one since the spinner started
+		elapsed = 0
+	}
+	return frames[(elapsed/frameDuration)%time.Duration(len(frames))]
+}
+
+// Pause freezes the spinner on its current frame until Resume() is called.
+func (s *Spinner) Pause() {
+	defer s.access.Unlock()
+	s.access.Lock()
+	if !s.pausedAt.IsZero() {
+		return
+	}
+	s.pausedAt = s.getClock().Now()
+	if s.startedAt.IsZero() {
+		s.startedAt = s.pausedAt
+	}
+}
+
+// Resume restarts the animation of a paused spinner from the frame it was frozen on.
+func (s *Spinner) Resume() {
+	defer s.access.Unlock()
+	s.access.Lock()
+	if s.pausedAt.IsZero() {
+		return
+	}
+	s.startedAt = s.startedAt.Add(s.getClock().Since(s.pausedAt))
+	s.pausedAt = time.Time{}
+}
+
+// Paused returns true if the spinner is currently paused.
+func (s *Spinner) Paused() bool {
+	defer s.access.Unlock()
+	s.access.Lock()
+	return !s.pausedAt.IsZero()
+}
+
+// Stop stops the spinner: from now on String() will always return final (for example a "✔" glyph).
+// final can be an empty string to make the spinner disappear.
+func (s *Spinner) Stop(final string) {
+	defer s.access.Unlock()
+	s.access.Lock()
+	s.stopped = true
+	s.final = final
+	requestRefresh()
+}
+
+// Stopped returns true if the spinner has been stopped.
+func (s *Spinner) Stopped() bool {
+	defer s.access.Unlock()
+	s.access.Lock()
+	return s.stopped
+}
+
+// Reset restarts a stopped or paused spinner from its first frame.
+func (s *Spinner) Reset() {
+	defer s.access.Unlock()
+	s.access.Lock()
+	s.startedAt = s.getClock().Now()
+	s.pausedAt = time.Time{}
+	s.stopped = false
+	s.final = ""
+	requestRefresh()
+}
+
+func (s *Spinner) successGlyph() string {
+	if s.successStyle == nil {
+		return BaseStyle().Foreground(termenv.ANSIGreen).Styled(DefaultSpinnerSuccess)
+	}
+	return s.successStyle.Styled(s.success)
+}
+
+func (s *Spinner) failureGlyph() string {
+	if s.failureStyle == nil {
+		return BaseStyle().Foreground(termenv.ANSIRed).Styled(DefaultSpinnerFailure)
+	}
+	return s.failureStyle.Styled(s.failure)
 }
@@ -0,0 +1,136 @@
+package liveprogress_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+)
+
+// newTestSpinner returns a spinner with the "a", "b" and "c" frames driven by a fake clock.
+func newTestSpinner(fps int) (spinner *liveprogress.Spinner, clock *liveprogresstest.FakeClock) {
+	clock = liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	spinner = liveprogress.NewSpinner(
+		liveprogress.WithSpinnerClock(clock),
+		liveprogress.WithSpinnerFPS(fps),
+		liveprogress.WithSpinnerFrames('a', 'b', 'c'),
+	)
+	return
+}
+
+// assertFrame marks the test as failed if the current frame of spinner is not expected.
+func assertFrame(t *testing.T, spinner *liveprogress.Spinner, expected string) {
+	t.Helper()
+	if frame := spinner.String(); frame != expected {
+		t.Errorf("expected frame %q, got %q", expected, frame)
+	}
+}
+
+func TestSpinnerFrames(t *testing.T) {
+	spinner, clock := newTestSpinner(4)
+	assertFrame(t, spinner, "a")
+	clock.Advance(200 * time.Millisecond)
+	assertFrame(t, spinner, "a")
+	clock.Advance(50 * time.Millisecond)
+	assertFrame(t, spinner, "b")
+	clock.Advance(250 * time.Millisecond)
+	assertFrame(t, spinner, "c")
+	clock.Advance(250 * time.Millisecond)
+	assertFrame(t, spinner, "a")
+	// frames only depend on the elapsed time, not on the number of calls
+	for i := 0; i < 5; i++ {
+		assertFrame(t, spinner, "a")
+	}
+	if next := spinner.Next(); next != "a" {
+		t.Errorf("Next() should be an alias of String(), got %q", next)
+	}
+}
+
+func TestSpinnerPauseResume(t *testing.T) {
+	spinner, clock := newTestSpinner(1)
+	clock.Advance(time.Second)
+	assertFrame(t, spinner, "b")
+	spinner.Pause()
+	spinner.Pause() // no-op, must not move the frozen time
+	if !spinner.Paused() {
+		t.Fatal("spinner should be paused")
+	}
+	clock.Advance(5 * time.Second)
+	assertFrame(t, spinner, "b")
+	spinner.Resume()
+	if spinner.Paused() {
+		t.Fatal("spinner should not be paused anymore")
+	}
+	assertFrame(t, spinner, "b")
+	clock.Advance(time.Second)
+	assertFrame(t, spinner, "c")
+}
+
+func TestSpinnerStopReset(t *testing.T) {
+	spinner, clock := newTestSpinner(1)
+	clock.Advance(time.Second)
+	spinner.Stop("done")
+	if !spinner.Stopped() {
+		t.Fatal("spinner should be stopped")
+	}
+	assertFrame(t, spinner, "done")
+	clock.Advance(time.Second)
+	assertFrame(t, spinner, "done")
+	spinner.Pause()
+	spinner.Reset()
+	if spinner.Stopped() || spinner.Paused() {
+		t.Fatal("spinner should neither be stopped nor paused after a reset")
+	}
+	assertFrame(t, spinner, "a")
+	clock.Advance(2 * time.Second)
+	assertFrame(t, spinner, "c")
+}
+
+func TestSpinnerConcurrent(t *testing.T) {
+	spinner, clock := newTestSpinner(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if frame := spinner.String(); frame != "a" && frame != "b" && frame != "c" {
+					t.Errorf("unexpected frame %q", frame)
+					return
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < 100; j++ {
+			clock.Advance(50 * time.Millisecond)
+			if j%10 == 0 {
+				spinner.Pause()
+			} else if j%10 == 5 {
+				spinner.Resume()
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestSpinnerTimeBounds(t *testing.T) {
+	// high frame rates must not overflow
+	spinner, clock := newTestSpinner(1e9)
+	clock.Advance(24 * time.Hour)
+	if frame := spinner.String(); frame != "a" && frame != "b" && frame != "c" {
+		t.Errorf("unexpected frame %q", frame)
+	}
+	// clock replaced by an earlier one after the spinner started
+	defaultClock := liveprogress.DefaultClock
+	t.Cleanup(func() { liveprogress.DefaultClock = defaultClock })
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	liveprogress.DefaultClock = liveprogresstest.NewFakeClock(start)
+	spinner = liveprogress.NewSpinner(liveprogress.WithSpinnerFrames('a', 'b', 'c'))
+	liveprogress.DefaultClock = liveprogresstest.NewFakeClock(start.Add(-time.Hour))
+	assertFrame(t, spinner, "a")
+}
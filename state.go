@@ -0,0 +1,122 @@
+package liveprogress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	stateVersion = 1
+)
+
+// state is the JSON document written by SaveState() and read by LoadState().
+type state struct {
+	Version int        `json:"version"`
+	Bars    []barState `json:"bars"`
+}
+
+type barState struct {
+	Name           string        `json:"name"`
+	Group          string        `json:"group,omitempty"`
+	Current        uint64        `json:"current"`
+	Total          uint64        `json:"total"`
+	ElapsedSeconds float64       `json:"elapsedSeconds"`
+	RateHistory    []rateHistory `json:"rateHistory,omitempty"`
+}
+
+// rateHistory is a rate sample, dated relatively to the time the state was saved.
+type rateHistory struct {
+	AgeSeconds float64 `json:"ageSeconds"`
+	Value      uint64  `json:"value"`
+}
+
+// SaveState writes the state of the registered named bars (see WithName()) as JSON to w: their current value, total,
+// active time (see Bar.ActiveDuration()) and rate history. Use LoadState() after a restart to resume them. Bars without a name are skipped.
+func SaveState(w io.Writer) (err error) {
+	saved := state{
+		Version: stateVersion,
+		Bars:    make([]barState, 0),
+	}
+	for _, bar := range registeredBars() {
+		if bar.name == "" {
+			continue
+		}
+		saved.Bars = append(saved.Bars, bar.saveState())
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "\t")
+	if err = encoder.Encode(saved); err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+	return
+}
+
+// LoadState reads a state written by SaveState() from r and restores it into the registered bars with the same name:
+// their current value, elapsed time and rate history are restored, keeping the time elapsed, time remaining and rate
+// decorators continuous across runs (the time between the runs is not accounted for). Bars totals are not restored: they are kept as created.
+// Call it once the bars to resume have been added. Saved bars without a matching registered bar are ignored.
+func LoadState(r io.Reader) (err error) {
+	var loaded state
+	if err = json.NewDecoder(r).Decode(&loaded); err != nil {
+		return fmt.Errorf("failed to decode state: %w", err)
+	}
+	if loaded.Version != stateVersion {
+		return fmt.Errorf("unsupported state version %d", loaded.Version)
+	}
+	saved := make(map[string]barState, len(loaded.Bars))
+	for _, bs := range loaded.Bars {
+		saved[bs.Name] = bs
+	}
+	for _, bar := range registeredBars() {
+		if bs, found := saved[bar.name]; found && bar.name != "" {
+			bar.loadState(bs)
+		}
+	}
+	return
+}
+
+func (pb *Bar) saveState() (bs barState) {
+	now := pb.clock.Now()
+	bs = barState{
+		Name:           pb.name,
+		Group:          pb.group,
+		Current:        pb.current.Load(),
+		Total:          pb.total,
+		ElapsedSeconds: pb.ActiveDuration().Seconds(),
+	}
+	defer pb.rateAccess.Unlock()
+	pb.rateAccess.Lock()
+	bs.RateHistory = make([]rateHistory, len(pb.rateSamples))
+	for index, sample := range pb.rateSamples {
+		bs.RateHistory[index] = rateHistory{
+			AgeSeconds: now.Sub(sample.at).Seconds(),
+			Value:      sample.value,
+		}
+	}
+	return
+}
+
+func (pb *Bar) loadState(bs barState) {
+	now := pb.clock.Now()
+	pb.current.Store(bs.Current)
+	pb.lastUpdate.Store(now.UnixNano())
+	requestRefresh()
+	// active time of the previous runs, minus the time this bar has already been active
+	pb.restoredElapsed.Store(int64(time.Duration(bs.ElapsedSeconds*float64(time.Second)) - pb.activeDuration()))
+	if len(bs.RateHistory) == 0 {
+		return
+	}
+	// samples are dated as if the bar had never been stopped
+	samples := make([]rateSample, len(bs.RateHistory))
+	for index, history := range bs.RateHistory {
+		samples[index] = rateSample{
+			at:    now.Add(-time.Duration(history.AgeSeconds * float64(time.Second))),
+			value: history.Value,
+		}
+	}
+	defer pb.rateAccess.Unlock()
+	pb.rateAccess.Lock()
+	pb.rateSamples = samples
+}
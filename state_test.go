@@ -0,0 +1,59 @@
+package liveprogress_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+)
+
+func TestSaveLoadState(t *testing.T) {
+	defer liveprogress.RemoveAll()
+	// first run
+	clock := liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	bar := liveprogress.AddBar(liveprogress.WithName("copy"), liveprogress.WithClock(clock))
+	liveprogress.AddBar(liveprogress.WithClock(clock)).CurrentSet(10) // unnamed, not saved
+	for i := 0; i < 6; i++ {
+		clock.Advance(10 * time.Second)
+		bar.CurrentAdd(4)
+		bar.Rate()
+	}
+	var saved bytes.Buffer
+	if err := liveprogress.SaveState(&saved); err != nil {
+		t.Fatalf("failed to save state: %s", err)
+	}
+	if strings.Count(saved.String(), `"name"`) != 1 {
+		t.Errorf("only named bars should be saved:\n%s", saved.String())
+	}
+	liveprogress.RemoveAll()
+	// second run, an hour later
+	clock = liveprogresstest.NewFakeClock(time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC))
+	resumed := liveprogress.AddBar(liveprogress.WithName("copy"), liveprogress.WithClock(clock))
+	other := liveprogress.AddBar(liveprogress.WithName("other"), liveprogress.WithClock(clock))
+	clock.Advance(5 * time.Second)
+	if err := liveprogress.LoadState(&saved); err != nil {
+		t.Fatalf("failed to load state: %s", err)
+	}
+	if resumed.Current() != 24 {
+		t.Errorf("expected current value of 24, got %d", resumed.Current())
+	}
+	if elapsed := resumed.Elapsed(); elapsed != time.Minute {
+		t.Errorf("expected elapsed time of 1m, got %s", elapsed)
+	}
+	if remaining, _ := resumed.Remaining(); remaining != 190*time.Second {
+		t.Errorf("expected remaining time of 3m10s, got %s", remaining)
+	}
+	if rate := resumed.Rate(); rate < 0.39 || rate > 0.41 {
+		t.Errorf("expected a rate of 0.4/s, got %f", rate)
+	}
+	clock.Advance(10 * time.Second)
+	if elapsed := resumed.Elapsed(); elapsed != time.Minute+10*time.Second {
+		t.Errorf("expected elapsed time of 1m10s, got %s", elapsed)
+	}
+	if other.Current() != 0 || other.Elapsed() != 15*time.Second {
+		t.Error("bar without saved state should not be modified")
+	}
+}
@@ -0,0 +1,512 @@
+package liveprogress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mattn/go-runewidth"
+	"github.com/muesli/ansi"
+	"github.com/muesli/termenv"
+	"golang.org/x/term"
+)
+
+const (
+	// resizeWait is the time to wait for the terminal size to be stable before drawing again after a resize.
+	resizeWait = 500 * time.Millisecond
+)
+
+var (
+	screen       *terminal // screen is the terminal the live progress is drawn on, nil if not running
+	screenAccess sync.Mutex
+	// last known size of the screen, readable without screenAccess (items are rendered while holding it)
+	screenCols, screenRows atomic.Int64
+	// termenv output of the screen, readable without screenAccess
+	screenOutput atomic.Pointer[termenv.Output]
+	// missing cursor movement from termenv
+	moveCursorBeginningOfTheLine = fmt.Sprintf(termenv.CSI+termenv.CursorHorizontalSeq, 0)
+	// used by the differential drawing
+	eraseLineRight    = termenv.CSI + termenv.EraseLineRightSeq
+	eraseDisplayBelow = fmt.Sprintf(termenv.CSI+termenv.EraseDisplaySeq, 0)
+	resetStyle        = termenv.CSI + termenv.ResetSeq + "m"
+)
+
+// terminal draws the live progress frames on a writer. When possible only the changes between the previous frame
+// and the new one are drawn (see drawDiff()), otherwise the previous frame is erased before writing the new one.
+type terminal struct {
+	writer     io.Writer
+	output     *termenv.Output
+	restore    func() error
+	cols, rows int
+	waitUntil  time.Time    // do not draw until the terminal size is stable
+	redraw     bool         // the terminal has been resized, the previous frame can not be trusted for diffing
+	delayed    bytes.Buffer // bypass writes received while waiting
+	frame      bytes.Buffer
+	lastFrame  bytes.Buffer
+	diff       bytes.Buffer
+	lines      []frameLine
+	lastLines  []frameLine
+}
+
+/*
+	Terminal capabilities
+*/
+
+// isTerminalFile returns whether w is a file descriptor backed terminal.
+func isTerminalFile(w io.Writer) bool {
+	file, ok := w.(interface{ Fd() uintptr })
+	return ok && (isatty.IsTerminal(file.Fd()) || isatty.IsCygwinTerminal(file.Fd()))
+}
+
+// outputIsTerminal returns whether w (Output or the terminal it stands for) should be considered as a terminal, see OutputIsTerminal.
+func outputIsTerminal(w io.Writer) bool {
+	if OutputIsTerminal != nil {
+		return OutputIsTerminal()
+	}
+	return isTerminalFile(w)
+}
+
+// outputSize returns the size of w (Output or the terminal it stands for), see OutputSize. 0 means unknown.
+func outputSize(w io.Writer) (cols, rows int) {
+	if OutputSize != nil {
+		return OutputSize()
+	}
+	file, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return
+	}
+	cols, rows, err := term.GetSize(int(file.Fd()))
+	if err != nil {
+		return 0, 0
+	}
+	return
+}
+
+// newTermOutput returns the termenv output for w. When w has been declared as a terminal with OutputIsTerminal,
+// termenv is told so in order to detect the color profile from the environment.
+func newTermOutput(w io.Writer) *termenv.Output {
+	if OutputIsTerminal != nil {
+		return termenv.NewOutput(w, termenv.WithTTY(OutputIsTerminal()))
+	}
+	return termenv.NewOutput(w)
+}
+
+// termOutput returns the termenv output of the screen if liveprogress is running, or the one of Output otherwise.
+func termOutput() *termenv.Output {
+	if output := screenOutput.Load(); output != nil {
+		return output
+	}
+	return newTermOutput(Output)
+}
+
+// termSize returns the last known size of the screen if liveprogress is running, or the size of Output otherwise.
+func termSize() (cols, rows int) {
+	if running.Load() {
+		return int(screenCols.Load()), int(screenRows.Load())
+	}
+	return outputSize(Output)
+}
+
+/*
+	Drawing
+*/
+
+// startScreen starts drawing the live progress on w.
+func startScreen(w io.Writer) (err error) {
+	defer screenAccess.Unlock()
+	screenAccess.Lock()
+	if screen != nil {
+		return errors.New("liveprogress is already started")
+	}
+	t := &terminal{
+		writer: w,
+		output: newTermOutput(w),
+	}
+	if t.restore, err = termenv.EnableVirtualTerminalProcessing(t.output); err != nil {
+		return fmt.Errorf("failed to enable virtual terminal processing: %w", err)
+	}
+	t.cols, t.rows = outputSize(w)
+	screenCols.Store(int64(t.cols))
+	screenRows.Store(int64(t.rows))
+	screenOutput.Store(t.output)
+	t.output.HideCursor()
+	screen = t
+	return
+}
+
+// stopScreen stops drawing the live progress: the last frame is either erased (clear) or drawn one last time.
+func stopScreen(clear bool) (err error) {
+	defer screenAccess.Unlock()
+	screenAccess.Lock()
+	if screen == nil {
+		return
+	}
+	t := screen
+	t.waitUntil = time.Time{}
+	if clear {
+		t.flushDelayed()
+		t.erase()
+		t.lastFrame.Reset()
+	} else {
+		t.update()
+		// separate the last frame from what will be written next
+		if t.lastFrame.Len() > 0 && t.lastFrame.Bytes()[t.lastFrame.Len()-1] != '\n' {
+			_, _ = t.output.WriteString("\n")
+		}
+	}
+	t.output.ShowCursor()
+	err = t.restore()
+	screen = nil
+	screenOutput.Store(nil)
+	screenCols.Store(0)
+	screenRows.Store(0)
+	return
+}
+
+// updateScreen draws a new frame, if liveprogress is running.
+func updateScreen() {
+	defer screenAccess.Unlock()
+	screenAccess.Lock()
+	if screen != nil {
+		screen.update()
+	}
+}
+
+// screenResized returns true if the terminal has been resized since the last frame, or is still waiting for its size to be stable.
+func screenResized() bool {
+	defer screenAccess.Unlock()
+	screenAccess.Lock()
+	if screen == nil || screen.cols == 0 {
+		return false
+	}
+	if screen.redraw {
+		return true
+	}
+	cols, rows := outputSize(screen.writer)
+	return cols != screen.cols || rows != screen.rows
+}
+
+// update draws a new frame over the previous one. It must be called with screenAccess locked.
+func (t *terminal) update() {
+	// Update terminal size for erase
+	if t.cols != 0 {
+		previousCols, previousRows := t.cols, t.rows
+		t.cols, t.rows = outputSize(t.writer)
+		screenCols.Store(int64(t.cols))
+		screenRows.Store(int64(t.rows))
+		if t.cols != previousCols || t.rows != previousRows {
+			// terminal has been resized, wait for stability before computing the lines to erase
+			// in case the terminal resizing is not done yet
+			t.waitUntil = time.Now().Add(resizeWait)
+			t.redraw = true
+			return
+		}
+		if t.waitUntil.After(time.Now()) {
+			return
+		}
+	}
+	t.flushDelayed()
+	// Render the new frame and draw it over the previous one: only its changes if possible, entirely otherwise
+	t.frame.Reset()
+	renderFrame(&t.frame, t.cols)
+	if t.redraw || !t.drawDiff() {
+		t.erase()
+		_, _ = t.output.Write(t.frame.Bytes())
+		t.redraw = false
+	}
+	t.lastFrame, t.frame = t.frame, t.lastFrame
+}
+
+// bypass writes p above the live area. It must be called with screenAccess locked.
+func (t *terminal) bypass(p []byte) (n int, err error) {
+	if t.waitUntil.After(time.Now()) {
+		// written once the terminal size is stable again
+		return t.delayed.Write(p)
+	}
+	t.erase()
+	if n, err = t.output.Write(p); err != nil {
+		return
+	}
+	_, err = t.output.Write(t.lastFrame.Bytes())
+	return
+}
+
+// flushDelayed writes the bypass writes received while waiting for the terminal size to be stable.
+func (t *terminal) flushDelayed() {
+	if t.delayed.Len() == 0 {
+		return
+	}
+	t.erase()
+	_, _ = t.output.Write(t.delayed.Bytes())
+	_, _ = t.output.Write(t.lastFrame.Bytes())
+	t.delayed.Reset()
+}
+
+// erase clears the lines occupied by the last frame. When the terminal width is known, long lines wrapped by the terminal are accounted for.
+func (t *terminal) erase() {
+	var (
+		linesCount, currentLineWidth, runeWidth int
+		withinTermSeq                           bool
+	)
+	for _, r := range t.lastFrame.String() {
+		// terminal sequences are not printed, ignore their runes width
+		if withinTermSeq {
+			if ansi.IsTerminator(r) {
+				withinTermSeq = false
+			}
+			continue
+		}
+		switch r {
+		case ansi.Marker:
+			withinTermSeq = true
+		case '\n':
+			linesCount++
+			currentLineWidth = 0
+		default:
+			if t.cols != 0 {
+				runeWidth = runewidth.RuneWidth(r)
+				currentLineWidth += runeWidth
+				if currentLineWidth > t.cols {
+					linesCount++
+					currentLineWidth = runeWidth
+				}
+			}
+		}
+	}
+	_, _ = t.output.WriteString(moveCursorBeginningOfTheLine)
+	t.output.ClearLine()
+	t.output.ClearLines(linesCount)
+}
+
+/*
+	Differential drawing
+*/
+
+// frameLine is a line of a frame, split into cells.
+type frameLine struct {
+	text  string
+	cells []frameCell
+	width int
+}
+
+// frameCell is a printable rune of a frame line, with the zero width runes following it.
+type frameCell struct {
+	start, end int    // bytes offsets within the line
+	col, width int    // columns
+	style      string // terminal sequences preceding the cell since the beginning of the line
+}
+
+// drawDiff draws the current frame over the last one by rewriting only the lines, and within them the spans, which have changed.
+// The cursor is expected at the last line of the last frame and is left at the last line of the current one.
+// It returns false without drawing anything if the frames can not be diffed: unknown terminal width, frame higher
+// than the terminal, lines wrapped by the terminal or containing control characters. The caller should then redraw entirely.
+func (t *terminal) drawDiff() bool {
+	if t.cols == 0 || t.lastFrame.Len() == 0 {
+		return false
+	}
+	var ok bool
+	if t.lastLines, ok = splitFrame(t.lastLines, t.lastFrame.String(), t.cols, t.rows); !ok {
+		return false
+	}
+	if t.lines, ok = splitFrame(t.lines, t.frame.String(), t.cols, t.rows); !ok {
+		return false
+	}
+	t.diff.Reset()
+	row := len(t.lastLines) - 1 // cursor row within the frame
+	for index := range t.lines {
+		line := &t.lines[index]
+		var previous *frameLine
+		if index < len(t.lastLines) {
+			previous = &t.lastLines[index]
+		}
+		first, last, changed := diffLine(previous, line)
+		if !changed {
+			continue
+		}
+		row = t.diffMoveToRow(row, index)
+		col := line.width
+		if first < len(line.cells) {
+			col = line.cells[first].col
+		}
+		fmt.Fprintf(&t.diff, termenv.CSI+termenv.CursorHorizontalSeq, col+1)
+		if first < len(line.cells) {
+			cell := line.cells[first]
+			t.diff.WriteString(cell.style)
+			if last == len(line.cells)-1 {
+				t.diff.WriteString(line.text[cell.start:])
+			} else {
+				span := line.text[cell.start:line.cells[last].end]
+				t.diff.WriteString(span)
+				if cell.style != "" || strings.IndexByte(span, ansi.Marker) >= 0 {
+					t.diff.WriteString(resetStyle)
+				}
+			}
+		}
+		if previous != nil && line.width < previous.width {
+			t.diff.WriteString(eraseLineRight)
+		}
+	}
+	// Remove the lines the current frame does not have anymore
+	if len(t.lines) < len(t.lastLines) {
+		row = t.diffMoveToRow(row, len(t.lines))
+		t.diff.WriteString(moveCursorBeginningOfTheLine)
+		t.diff.WriteString(eraseDisplayBelow)
+	}
+	t.diffMoveToRow(row, len(t.lines)-1)
+	if t.diff.Len() > 0 {
+		_, _ = t.output.Write(t.diff.Bytes())
+	}
+	return true
+}
+
+// diffMoveToRow moves the cursor from the frame row from to the frame row to. Rows after the last one of the
+// last frame do not exist yet on the terminal: they are created by new lines.
+func (t *terminal) diffMoveToRow(from, to int) (row int) {
+	switch {
+	case to < from:
+		fmt.Fprintf(&t.diff, termenv.CSI+termenv.CursorUpSeq, from-to)
+	case to > from:
+		if existing := min(to, len(t.lastLines)-1) - from; existing > 0 {
+			fmt.Fprintf(&t.diff, termenv.CSI+termenv.CursorDownSeq, existing)
+			from += existing
+		}
+		t.diff.WriteString(strings.Repeat("\n", to-from))
+	}
+	return to
+}
+
+// diffLine returns the span of cells of line to draw over previous (nil if the line is new). The span ends at the
+// end of the line unless the lines have the same width and share the cells after it.
+func diffLine(previous, line *frameLine) (first, last int, changed bool) {
+	if previous == nil {
+		return 0, len(line.cells) - 1, true
+	}
+	if previous.text == line.text {
+		return
+	}
+	for first < len(line.cells) && first < len(previous.cells) && sameCell(previous, line, first, first) {
+		first++
+	}
+	if first == len(line.cells) && first == len(previous.cells) {
+		// only the terminal sequences after the last cell differ
+		return
+	}
+	last = len(line.cells) - 1
+	if previous.width == line.width {
+		for previousLast := len(previous.cells) - 1; last > first && sameCell(previous, line, previousLast, last); previousLast-- {
+			last--
+		}
+	}
+	return first, last, true
+}
+
+func sameCell(previous, line *frameLine, previousIndex, index int) bool {
+	previousCell, cell := previous.cells[previousIndex], line.cells[index]
+	return previousCell.col == cell.col && previousCell.style == cell.style &&
+		previous.text[previousCell.start:previousCell.end] == line.text[cell.start:cell.end]
+}
+
+// splitFrame splits frame into lines (reusing lines storage). ok is false if the frame can not be diffed, see drawDiff().
+func splitFrame(lines []frameLine, frame string, cols, rows int) (split []frameLine, ok bool) {
+	split = lines[:0]
+	for _, text := range strings.Split(frame, "\n") {
+		if rows != 0 && len(split) == rows {
+			return split, false
+		}
+		var cells []frameCell
+		if len(split) < cap(split) {
+			cells = split[:len(split)+1][len(split)].cells
+		}
+		line := frameLine{text: text}
+		if line.cells, line.width, ok = splitLine(cells, text, cols); !ok {
+			return
+		}
+		split = append(split, line)
+	}
+	return split, true
+}
+
+// splitLine splits line into cells (reusing cells storage). ok is false if the line contains control characters or is wider than cols.
+func splitLine(cells []frameCell, line string, cols int) (split []frameCell, width int, ok bool) {
+	split = cells[:0]
+	var style string
+	for index := 0; index < len(line); {
+		if line[index] == ansi.Marker {
+			end := termSequenceEnd(line, index)
+			style += line[index:end]
+			index = end
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(line[index:])
+		if r < 0x20 || r == 0x7f {
+			return
+		}
+		runeWidth := runewidth.RuneWidth(r)
+		if runeWidth == 0 && len(split) > 0 {
+			split[len(split)-1].end = index + size
+			index += size
+			continue
+		}
+		split = append(split, frameCell{
+			start: index,
+			end:   index + size,
+			col:   width,
+			width: runeWidth,
+			style: style,
+		})
+		if width += runeWidth; width > cols {
+			return
+		}
+		index += size
+	}
+	return split, width, true
+}
+
+// termSequenceEnd returns the offset following the terminal sequence starting at start within s.
+func termSequenceEnd(s string, start int) int {
+	if start+1 >= len(s) {
+		return len(s)
+	}
+	switch s[start+1] {
+	case '[':
+		// CSI: parameters then a final byte
+		for index := start + 2; index < len(s); index++ {
+			if s[index] >= 0x40 && s[index] <= 0x7e {
+				return index + 1
+			}
+		}
+	case ']':
+		// OSC: terminated by BEL or ST
+		for index := start + 2; index < len(s); index++ {
+			switch {
+			case s[index] == '\a':
+				return index + 1
+			case s[index] == ansi.Marker && index+1 < len(s) && s[index+1] == '\\':
+				return index + 2
+			}
+		}
+	default:
+		return start + 2
+	}
+	return len(s)
+}
+
+// bypassWriter writes above the live area while liveprogress is running and to Output otherwise.
+type bypassWriter struct{}
+
+func (bypassWriter) Write(p []byte) (n int, err error) {
+	dashboardLogs.publish(p)
+	defer screenAccess.Unlock()
+	screenAccess.Lock()
+	if screen == nil {
+		return Output.Write(p)
+	}
+	return screen.bypass(p)
+}
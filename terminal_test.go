@@ -0,0 +1,59 @@
+package liveprogress_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hekmon/liveprogress/v2"
+	"github.com/hekmon/liveprogress/v2/liveprogresstest"
+)
+
+// recorder records the writes to a terminal.
+type recorder struct {
+	*liveprogresstest.Terminal
+	writes bytes.Buffer
+}
+
+func (r *recorder) Write(p []byte) (n int, err error) {
+	r.writes.Write(p)
+	return r.Terminal.Write(p)
+}
+
+// tick draws a frame and returns what has been written for it.
+func (r *recorder) tick() string {
+	r.writes.Reset()
+	liveprogress.Tick()
+	return r.writes.String()
+}
+
+func TestDiffDrawing(t *testing.T) {
+	terminal := &recorder{Terminal: liveprogresstest.NewTerminal(30, 8)}
+	useOutput(t, terminal, func() bool { return true }, terminal.Size)
+	if err := liveprogress.Start(); err != nil {
+		t.Fatalf("failed to start: %s", err)
+	}
+	defer liveprogress.Stop(true)
+	bars := make([]*liveprogress.Bar, 3)
+	for index := range bars {
+		bars[index] = liveprogress.AddBar(liveprogress.WithWidth(10), liveprogress.WithAppendPercent(liveprogress.BaseStyle()))
+	}
+	status := "starting ✓"
+	liveprogress.AddCustomLine(func() string { return status })
+	terminal.tick()
+	if written := terminal.tick(); written != "" {
+		t.Errorf("an unchanged frame should not be drawn again: %q", written)
+	}
+	// only the changed spans are drawn
+	bars[1].CurrentSet(50)
+	if written, expected := terminal.tick(), "\x1b[2A\x1b[2G===>----]  5\x1b[2B"; written != expected {
+		t.Errorf("only the changed span of the second bar should be drawn: expected %q, got %q", expected, written)
+	}
+	status = "running ✓"
+	terminal.tick()
+	// lines removed, shortened and added
+	liveprogress.RemoveBar(bars[0])
+	status = "done"
+	liveprogress.AddCustomLine(func() string { return "日本語" })
+	terminal.tick()
+	liveprogresstest.AssertGolden(t, "diff_drawing", terminal.String())
+}